@@ -86,13 +86,13 @@ func (c *cache) SetDefault(k string, x interface{}) {
 }
 
 // 仅当给定键不存在项目或现有项目已过期时，向缓存添加项目
-// 否则返回错误
+// 否则返回包装了ErrKeyExists的错误
 func (c *cache) Add(k string, x interface{}, d time.Duration) error {
 	c.mu.Lock()
 	_, found := c.get(k)
 	if found {
 		c.mu.Unlock()
-		return fmt.Errorf("Item %s already exists", k)
+		return fmt.Errorf("%w: %s", ErrKeyExists, k)
 	}
 	c.set(k, x, d)
 	c.mu.Unlock()
@@ -100,13 +100,13 @@ func (c *cache) Add(k string, x interface{}, d time.Duration) error {
 }
 
 // 仅当缓存键已存在且现有项目未过期时，设置新值
-// 否则返回错误
+// 否则返回包装了ErrKeyNotFound的错误
 func (c *cache) Replace(k string, x interface{}, d time.Duration) error {
 	c.mu.Lock()
 	_, found := c.get(k)
 	if !found {
 		c.mu.Unlock()
-		return fmt.Errorf("Item %s doesn't exist", k)
+		return fmt.Errorf("%w: %s", ErrKeyNotFound, k)
 	}
 	c.set(k, x, d)
 	c.mu.Unlock()
@@ -1,13 +1,33 @@
 package cache
 
 import (
+	"bytes"
+	"compress/gzip"
 	"encoding/gob"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"math/rand"
 	"os"
+	"path/filepath"
 	"runtime"
+	"sort"
 	"sync"
+	"sync/atomic"
 	"time"
+	"unsafe"
+)
+
+var (
+	// ErrNotFound 表示键不存在或已过期，可以用errors.Is判断，参见GetOrError
+	ErrNotFound = errors.New("Item not found")
+	// ErrKeyNotFound 是Replace等要求键已存在的方法在键不存在或已过期时返回的错误（包装了键名），与ErrNotFound相同
+	ErrKeyNotFound = ErrNotFound
+	// ErrKeyExists 是Add在键已存在且未过期时返回的错误（包装了键名）
+	ErrKeyExists = errors.New("Item already exists")
+	// ErrClosed 是GetOrLoad等需要加载的方法在CachePro已关闭时返回的错误
+	ErrClosed = errors.New("CachePro is closed")
 )
 
 type CachePro[T any] struct {
@@ -17,6 +37,19 @@ type CachePro[T any] struct {
 type ItemPro[T any] struct {
 	Object     T
 	Expiration int64
+	// 为true时表示这是一个通过SetNegative缓存的"不存在"结果，Object为零值
+	Negative bool `json:",omitempty"`
+	// 最近一次写入或读取的时间（UnixNano），仅在启用WithAccessTracking时记录
+	LastAccess int64 `json:",omitempty"`
+	// 硬过期时间（UnixNano），大于Expiration时项目在两者之间仍可通过GetStale读取，参见SetWithHardTTL
+	HardExpiration int64 `json:",omitempty"`
+	// 驱逐优先级，参见SetWithPriority
+	Priority int `json:",omitempty"`
+	// 每次写入时从CachePro的全局计数器分配的版本号，参见GetVersioned和SetIfVersion
+	Version uint64 `json:",omitempty"`
+	// 附加在项目上的标签，未使用时为nil，参见SetWithTags
+	// 使用指针以保持ItemPro可以用==比较；指向的映射写入后不会再被修改
+	Tags *map[string]string `json:",omitempty"`
 }
 
 // 如果项目已过期则返回true
@@ -33,96 +66,414 @@ type cachePro[T any] struct {
 	mu                sync.RWMutex
 	onEvicted         func(string, interface{})
 	janitor           *janitorPro[T]
+	jmu               sync.Mutex
+	cleanupInterval   time.Duration
 	delFunc           func(T)
+	loadMu            sync.Mutex
+	loads             map[string]*loadCall[T]
+	workers           *workerPool
+	loadWorkers       *workerPool
+	validator         func(string, T) bool
+	validateEvery     int
+	validateTicks     int
+	opLog             *opLogWriter
+	computeRenewTTL   bool
+	computeDefaultTTL bool
+	dupPolicy         DuplicateKeyPolicy
+	evictQueues       atomic.Pointer[evictionQueues]
+	maxBytes          int64
+	sizeOf            func(T) int64
+	curBytes          int64
+	policy            Policy
+	prioritized       int
+	mirror            *mirrorPro[T]
+	hits              atomic.Uint64
+	misses            atomic.Uint64
+	evictions         atomic.Uint64
+	trackAccess       bool
+	clock             clock
+	writeBehind       *writeBehindPro[T]
+	onExpired         func(string, T)
+	lazyEvict         bool
+	maxTTL            time.Duration
+	jitter            float64
+	watchers          []chan CacheEvent
+	version           uint64
+	loader            func(string) (T, time.Duration, bool)
+	zeroPolicy        ZeroDurationPolicy
+	closeOnFinalize   bool
+	noFinalizer       bool
+	closed            atomic.Bool
+	waiters           map[string][]chan struct{}
+	defaultValue      T
 }
 
 // 向CachePro添加一个项目，替换任何现有项目。如果持续时间为0
 // (DefaultExpiration)，则使用CachePro的默认过期时间。如果为-1
 // (NoExpiration)，则项目永不过期。
 func (c *CachePro[T]) Set(k string, x T, d time.Duration) {
+	c.mustBeOpen()
 	// "Inlining" of set
-	var e int64
-	if d == DefaultExpiration {
-		d = c.defaultExpiration
-	}
-	if d > 0 {
-		e = time.Now().Add(d).UnixNano()
-	}
 	c.mu.Lock()
-	c.items[k] = ItemPro[T]{
+	c.overwrite(k, ItemPro[T]{
 		Object:     x,
-		Expiration: e,
+		Expiration: c.expiration(d),
+	})
+	if c.maxBytes > 0 {
+		c.unlockAndEvict(k)
+		return
 	}
 	// TODO: Calls to mu.Unlock are currently not deferred because defer
 	// adds ~200 ns (as of go1.)
 	c.mu.Unlock()
 }
 
-func (c *cachePro[T]) set(k string, x T, d time.Duration) {
+// EntryPro 是SetManyWithExpiration的一个条目：值及其过期时间
+type EntryPro[T any] struct {
+	Value T
+	// 与Set的参数d含义相同，可以是DefaultExpiration或NoExpiration
+	D time.Duration
+}
+
+// SetManyWithExpiration 在同一个写锁内写入items中的所有项目，每个项目使用各自的过期时间，替换任何现有项目
+// 有内存上限时，这次写入触发的驱逐不会驱逐本批写入的任何项目
+func (c *CachePro[T]) SetManyWithExpiration(items map[string]EntryPro[T]) {
+	c.mustBeOpen()
+	keys := make([]string, 0, len(items))
+	c.mu.Lock()
+	for k, e := range items {
+		c.set(k, e.Value, e.D)
+		keys = append(keys, k)
+	}
+	c.unlockAndEvict(keys...)
+}
+
+// SetAll 在同一个写锁内以过期时间d写入items中的所有元素，每个元素的键由keyOf计算，替换任何现有项目
+// 多个元素的键相同时以后出现的为准
+// 有内存上限时，这次写入触发的驱逐不会驱逐本批写入的任何项目
+func SetAll[T any](c *CachePro[T], items []T, keyOf func(T) string, d time.Duration) {
+	c.mustBeOpen()
+	if len(items) == 0 {
+		return
+	}
+	keys := make([]string, len(items))
+	c.mu.Lock()
+	for i, x := range items {
+		keys[i] = keyOf(x)
+		c.set(keys[i], x, d)
+	}
+	c.unlockAndEvict(keys...)
+}
+
+// SetWithDeadline 向CachePro添加项目，在绝对时间deadline过期，替换任何现有项目
+// deadline为零值时项目永不过期；deadline已经过去时项目会立即被视为过期
+func (c *CachePro[T]) SetWithDeadline(k string, x T, deadline time.Time) {
+	c.mustBeOpen()
+	c.mu.Lock()
+	c.overwrite(k, ItemPro[T]{
+		Object:     x,
+		Expiration: c.deadlineExpiration(deadline),
+	})
+	c.unlockAndEvict(k)
+}
+
+// SetExpiration 只修改已存在且未过期的项目的过期时间为绝对时间deadline，不改变其值，并返回是否修改
+// deadline的含义与SetWithDeadline相同：零值表示永不过期，已经过去的时间会使项目立即被视为过期
+func (c *CachePro[T]) SetExpiration(k string, deadline time.Time) bool {
+	c.mustBeOpen()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	item, found := c.items[k]
+	if !found || c.expired(item) {
+		return false
+	}
+	item.Expiration = c.deadlineExpiration(deadline)
+	c.put(k, item)
+	return true
+}
+
+// 返回绝对时间deadline对应的过期时间（UnixNano），同样受SetMaxTTL限制，调用方必须持有锁
+func (c *cachePro[T]) deadlineExpiration(deadline time.Time) int64 {
 	var e int64
-	if d == DefaultExpiration {
-		d = c.defaultExpiration
+	if !deadline.IsZero() {
+		// 1970年之前的时间会得到非正数，而非正数表示永不过期
+		e = max(deadline.UnixNano(), 1)
 	}
-	if d > 0 {
-		e = time.Now().Add(d).UnixNano()
+	if capped := c.expiration(NoExpiration); capped > 0 && (e == 0 || e > capped) {
+		e = capped
 	}
-	c.items[k] = ItemPro[T]{
+	return e
+}
+
+func (c *cachePro[T]) set(k string, x T, d time.Duration) {
+	c.overwrite(k, ItemPro[T]{
 		Object:     x,
-		Expiration: e,
+		Expiration: c.expiration(d),
+	})
+}
+
+// 返回以持续时间d计算出的过期时间（UnixNano），0表示永不过期
+// 所有根据持续时间计算过期时间的写入都应经过此方法，调用方必须持有锁
+func (c *cachePro[T]) expiration(d time.Duration) int64 {
+	if d == DefaultExpiration {
+		if c.zeroPolicy == ZeroMeansNoExpiration {
+			d = NoExpiration
+		} else {
+			d = c.defaultExpiration
+		}
+	}
+	if d > 0 && c.jitter > 0 {
+		d += time.Duration((rand.Float64()*2 - 1) * c.jitter * float64(d))
+		d = max(d, 1)
+	}
+	if c.maxTTL > 0 && (d <= 0 || d > c.maxTTL) {
+		d = c.maxTTL
 	}
+	if d > 0 {
+		return c.clock.Now().Add(d).UnixNano()
+	}
+	return 0
+}
+
+// 使用keyFunc从x计算出键，以该键向CachePro添加x（替换任何现有项目），并返回该键
+func (c *CachePro[T]) SetByValue(x T, keyFunc func(T) string, d time.Duration) string {
+	k := keyFunc(x)
+	c.Set(k, x, d)
+	return k
 }
 
 // 向CachePro添加一个项目，替换任何现有项目，使用默认过期时间
 func (c *CachePro[T]) SetDefault(k string, x T) {
-	c.Set(k, x, DefaultExpiration)
+	c.Set(k, x, c.defaultExpiration)
 }
 
 // 仅当给定键不存在项目或现有项目已过期时，向CachePro添加项目
-// 否则返回错误
+// 否则返回包装了ErrKeyExists的错误
 func (c *CachePro[T]) Add(k string, x T, d time.Duration) error {
+	c.mustBeOpen()
 	c.mu.Lock()
 	_, found := c.get(k)
 	if found {
 		c.mu.Unlock()
-		return fmt.Errorf("Item %s already exists", k)
+		return fmt.Errorf("%w: %s", ErrKeyExists, k)
 	}
 	c.set(k, x, d)
-	c.mu.Unlock()
+	c.unlockAndEvict(k)
 	return nil
 }
 
 // 仅当CachePro键已存在且现有项目未过期时，设置新值
-// 否则返回错误
+// 否则返回包装了ErrKeyNotFound的错误
 func (c *CachePro[T]) Replace(k string, x T, d time.Duration) error {
+	c.mustBeOpen()
 	c.mu.Lock()
 	_, found := c.get(k)
 	if !found {
 		c.mu.Unlock()
-		return fmt.Errorf("Item %s doesn't exist", k)
+		return fmt.Errorf("%w: %s", ErrKeyNotFound, k)
 	}
 	c.set(k, x, d)
-	c.mu.Unlock()
+	c.unlockAndEvict(k)
 	return nil
 }
 
+// 与Replace相同，但保留项目原有的过期时间（以及硬过期时间和驱逐优先级），只替换值
+// 键不存在或已过期时返回与Replace相同的错误
+func (c *CachePro[T]) ReplaceKeepTTL(k string, x T) error {
+	c.mustBeOpen()
+	c.mu.Lock()
+	item, found := c.items[k]
+	if !found || item.Negative || c.expired(item) {
+		c.mu.Unlock()
+		return fmt.Errorf("%w: %s", ErrKeyNotFound, k)
+	}
+	item.Object = x
+	item.Version = 0
+	c.overwrite(k, item)
+	c.unlockAndEvict(k)
+	return nil
+}
+
+// 仅当给定键不存在项目或现有项目已过期时，向CachePro设置新值，并返回是否写入
+// 这与Add的判断条件相同（Add同样把已过期的项目视为不存在），区别在于冲突时返回false而不是错误，
+// 适合只刷新过期条目而保留新鲜值的场景
+func (c *CachePro[T]) SetIfExpired(k string, x T, d time.Duration) bool {
+	c.mustBeOpen()
+	c.mu.Lock()
+	_, found := c.get(k)
+	if found {
+		c.mu.Unlock()
+		return false
+	}
+	c.set(k, x, d)
+	c.unlockAndEvict(k)
+	return true
+}
+
+// TryAdd 与Redis的SETNX类似：仅当给定键不存在项目或现有项目已过期时写入，并返回是否写入
+// 检查和写入在同一个写锁内完成，因此并发调用同一个键时只有一个调用者会得到true，适合实现简单的锁
+// 与SetIfExpired相同，提供这个名称是为了让"抢占"的用法更易读
+func (c *CachePro[T]) TryAdd(k string, x T, d time.Duration) bool {
+	return c.SetIfExpired(k, x, d)
+}
+
+// Swap 以过期时间d存储新值x，并返回之前未过期的值以及是否存在该值
+// 读取旧值和写入新值在同一个写锁内完成；已过期的旧值视为不存在
+func (c *CachePro[T]) Swap(k string, x T, d time.Duration) (old T, hadOld bool) {
+	c.mustBeOpen()
+	c.mu.Lock()
+	old, hadOld = c.get(k)
+	if hadOld {
+		// 旧值交还给调用方，不传给delFunc
+		c.put(k, ItemPro[T]{
+			Object:     x,
+			Expiration: c.expiration(d),
+		})
+	} else {
+		c.set(k, x, d)
+	}
+	c.unlockAndEvict(k)
+	return old, hadOld
+}
+
+// CompareAndSwap 仅当键k当前未过期的值等于old时，以过期时间d存储new，并返回是否进行了替换
+// 比较和写入在同一个写锁内完成。键不存在或已过期时总是返回false，即使old是零值也是如此，
+// 以免把"不存在"和"值为零值"混为一谈；需要仅在键不存在时写入请使用Add
+func CompareAndSwap[T comparable](c *CachePro[T], k string, old, new T, d time.Duration) bool {
+	c.mustBeOpen()
+	c.mu.Lock()
+	current, found := c.get(k)
+	if !found || current != old {
+		c.mu.Unlock()
+		return false
+	}
+	c.set(k, new, d)
+	c.unlockAndEvict(k)
+	return true
+}
+
+// GetAs 从值类型为interface{}的CachePro中读取键k并断言为V，免去每个调用处的类型断言
+// 键不存在、已过期或值不是V类型时返回V的零值和false，不会panic
+func GetAs[V any](c *CachePro[interface{}], k string) (V, bool) {
+	x, found := c.Get(k)
+	if !found {
+		var zero V
+		return zero, false
+	}
+	v, ok := x.(V)
+	return v, ok
+}
+
+// GetVersioned 从CachePro返回项目、其版本号以及是否找到未过期的键
+// 每次写入都会为项目分配一个新的、比之前所有版本号都大的版本号，可以配合SetIfVersion实现乐观并发控制
+func (c *CachePro[T]) GetVersioned(k string) (T, uint64, bool) {
+	c.mu.RLock()
+	item, found := c.items[k]
+	c.mu.RUnlock()
+	if !found || item.Negative || c.expired(item) {
+		var zero T
+		return zero, 0, false
+	}
+	return item.Object, item.Version, true
+}
+
+// SetIfVersion 仅当键k当前的版本号等于expectedVersion时以过期时间d写入x，并返回是否写入
+// expectedVersion为0时表示仅在键不存在或已过期时写入。比较和写入在同一个写锁内完成
+func (c *CachePro[T]) SetIfVersion(k string, x T, expectedVersion uint64, d time.Duration) bool {
+	c.mustBeOpen()
+	c.mu.Lock()
+	var current uint64
+	if item, found := c.items[k]; found && !item.Negative && !c.expired(item) {
+		current = item.Version
+	}
+	if current != expectedVersion {
+		c.mu.Unlock()
+		return false
+	}
+	c.set(k, x, d)
+	c.unlockAndEvict(k)
+	return true
+}
+
 // 从CachePro获取项目。返回项目或零值，以及一个布尔值指示是否找到键
 func (c *CachePro[T]) Get(k string) (T, bool) {
+	if c.trackAccess {
+		return c.getTracked(k)
+	}
 	c.mu.RLock()
 	// "Inlining" of get and Expired
 	item, found := c.items[k]
 	if !found {
 		c.mu.RUnlock()
+		c.misses.Add(1)
+		if c.loader != nil {
+			return c.loadMissing(k)
+		}
 		var zero T
 		return zero, false
 	}
 	if item.Expiration > 0 {
-		if time.Now().UnixNano() > item.Expiration {
+		if c.clock.Now().UnixNano() > item.Expiration {
 			c.mu.RUnlock()
+			c.misses.Add(1)
+			if c.lazyEvict {
+				c.evictIfExpired(k)
+			}
+			if c.loader != nil {
+				return c.loadMissing(k)
+			}
 			var zero T
 			return zero, false
 		}
 	}
+	if item.Negative {
+		c.mu.RUnlock()
+		c.misses.Add(1)
+		var zero T
+		return zero, false
+	}
+	if c.policy != nil {
+		c.policy.RecordAccess(k)
+	}
 	c.mu.RUnlock()
+	c.hits.Add(1)
+	return item.Object, true
+}
+
+// GetOrError 与Get相同，但键不存在或已过期时返回包装了ErrNotFound的错误，而不是false
+func (c *CachePro[T]) GetOrError(k string) (T, error) {
+	v, found := c.Get(k)
+	if !found {
+		return v, fmt.Errorf("%w: %s", ErrNotFound, k)
+	}
+	return v, nil
+}
+
+// GetOrDefault 与Get相同，但键不存在或已过期时返回WithDefaultValue设置的默认值，而不是零值和false
+func (c *CachePro[T]) GetOrDefault(k string) T {
+	if v, found := c.Get(k); found {
+		return v
+	}
+	return c.defaultValue
+}
+
+// GetNoExpiryCheck 与Get相同，但完全跳过过期检查（以及WithLoader、WithAccessTracking和WithLazyEvict的处理），
+// 适用于以NoExpiration创建且从不设置单独过期时间的CachePro中的热点读取
+// 如果CachePro中有设置了过期时间的项目，已过期但尚未清理的项目也会被返回
+func (c *CachePro[T]) GetNoExpiryCheck(k string) (T, bool) {
+	c.mu.RLock()
+	item, found := c.items[k]
+	if !found || item.Negative {
+		c.mu.RUnlock()
+		c.misses.Add(1)
+		var zero T
+		return zero, false
+	}
+	if c.policy != nil {
+		c.policy.RecordAccess(k)
+	}
+	c.mu.RUnlock()
+	c.hits.Add(1)
 	return item.Object, true
 }
 
@@ -133,39 +484,169 @@ func (c *CachePro[T]) GetWithExpiration(k string) (T, time.Time, bool) {
 	c.mu.RLock()
 	// "Inlining" of get and Expired
 	item, found := c.items[k]
-	if !found {
+	if !found || item.Negative {
 		c.mu.RUnlock()
+		c.misses.Add(1)
 		var zero T
 		return zero, time.Time{}, false
 	}
 
 	if item.Expiration > 0 {
-		if time.Now().UnixNano() > item.Expiration {
+		if c.clock.Now().UnixNano() > item.Expiration {
 			c.mu.RUnlock()
+			c.misses.Add(1)
+			if c.lazyEvict {
+				c.evictIfExpired(k)
+			}
 			var zero T
 			return zero, time.Time{}, false
 		}
 
 		// Return the item and the expiration time
 		c.mu.RUnlock()
+		c.hits.Add(1)
 		return item.Object, time.Unix(0, item.Expiration), true
 	}
 
 	// If expiration <= 0 (i.e. no expiration time set) then return the item
 	// and a zeroed time.Time
 	c.mu.RUnlock()
+	c.hits.Add(1)
 	return item.Object, time.Time{}, true
 }
 
+// GetMultiWithMisses 在同一个读锁内获取keys中的所有项目，返回找到的项目，
+// 以及按请求顺序排列的未找到的键（不存在、已过期或负缓存的键都算作未找到）
+func (c *CachePro[T]) GetMultiWithMisses(keys []string) (found map[string]T, missing []string) {
+	found = make(map[string]T, len(keys))
+	c.mu.RLock()
+	for _, k := range keys {
+		if v, ok := c.get(k); ok {
+			found[k] = v
+		} else {
+			missing = append(missing, k)
+		}
+	}
+	c.mu.RUnlock()
+	return found, missing
+}
+
+// GetManyWithExpiration 在同一个读锁内获取keys中的所有项目，返回找到的项目（包括值和过期时间等元数据）
+// 不存在、已过期或负缓存的键不会出现在结果中
+func (c *CachePro[T]) GetManyWithExpiration(keys []string) map[string]ItemPro[T] {
+	res := make(map[string]ItemPro[T], len(keys))
+	c.mu.RLock()
+	for _, k := range keys {
+		item, found := c.items[k]
+		if !found || item.Negative || c.expired(item) {
+			continue
+		}
+		res[k] = item
+	}
+	c.mu.RUnlock()
+	return res
+}
+
+// Has 报告CachePro中是否存在未过期的键。与Get不同，它不会复制项目的值
+func (c *CachePro[T]) Has(k string) bool {
+	// 只读取需要的字段，不复制整个项目：T很大时复制Object的开销远大于查找本身
+	c.mu.RLock()
+	_, found := c.items[k]
+	var negative bool
+	var expiration int64
+	if found {
+		negative = c.items[k].Negative
+		expiration = c.items[k].Expiration
+	}
+	c.mu.RUnlock()
+	if !found || negative {
+		c.misses.Add(1)
+		return false
+	}
+	if c.expiredAt(expiration) {
+		c.misses.Add(1)
+		if c.lazyEvict {
+			c.evictIfExpired(k)
+		}
+		return false
+	}
+	c.hits.Add(1)
+	return true
+}
+
+// EntryState 表示GetEntry找到的项目的状态
+type EntryState int
+
+const (
+	// 项目存在且保存了一个值
+	EntryPresent EntryState = iota
+	// 项目是通过SetNegative缓存的"不存在"结果
+	EntryNegative
+)
+
+// SetNegative 在CachePro中为键k缓存一个"不存在"的结果，过期时间为d（通常比正常结果更短），
+// 用于在数据源确认键不存在时避免重复查询。
+// Get、GetWithExpiration和Has会把此类项目当作不存在，使用GetEntry可以将其与未缓存区分开
+func (c *CachePro[T]) SetNegative(k string, d time.Duration) {
+	c.mustBeOpen()
+	c.mu.Lock()
+	c.overwrite(k, ItemPro[T]{
+		Expiration: c.expiration(d),
+		Negative:   true,
+	})
+	c.unlockAndEvict(k)
+}
+
+// GetEntry 从CachePro获取项目及其状态。对于未过期的项目返回值（负缓存项目为零值）、
+// 其状态以及true；如果键不存在或已过期则返回零值、EntryPresent和false
+func (c *CachePro[T]) GetEntry(k string) (T, EntryState, bool) {
+	c.mu.RLock()
+	item, found := c.items[k]
+	c.mu.RUnlock()
+	if !found || c.expired(item) {
+		c.misses.Add(1)
+		if found && c.lazyEvict {
+			c.evictIfExpired(k)
+		}
+		var zero T
+		return zero, EntryPresent, false
+	}
+	// 负缓存项目也是一次命中：调用方得到了缓存的"不存在"结果，不需要再查询数据源
+	c.hits.Add(1)
+	if item.Negative {
+		return item.Object, EntryNegative, true
+	}
+	return item.Object, EntryPresent, true
+}
+
+// GetTTL 返回项目距离过期的剩余时间，以及一个布尔值指示是否找到未过期的键
+// 对于永不过期的项目返回NoExpiration（-1），对于不存在、已过期或负缓存的项目返回(0, false)
+func (c *CachePro[T]) GetTTL(k string) (time.Duration, bool) {
+	c.mu.RLock()
+	item, found := c.items[k]
+	c.mu.RUnlock()
+	if !found || item.Negative {
+		return 0, false
+	}
+	if item.Expiration <= 0 {
+		return NoExpiration, true
+	}
+	ttl := time.Duration(item.Expiration - c.clock.Now().UnixNano())
+	if ttl < 0 {
+		return 0, false
+	}
+	return ttl, true
+}
+
 func (c *cachePro[T]) get(k string) (T, bool) {
 	item, found := c.items[k]
-	if !found {
+	if !found || item.Negative {
 		var zero T
 		return zero, false
 	}
 	// "Inlining" of Expired
 	if item.Expiration > 0 {
-		if time.Now().UnixNano() > item.Expiration {
+		if c.clock.Now().UnixNano() > item.Expiration {
 			var zero T
 			return zero, false
 		}
@@ -179,27 +660,265 @@ func (c *CachePro[T]) Delete(k string) {
 	v, evicted := c.delete(k)
 	c.mu.Unlock()
 	if evicted {
-		c.onEvicted(k, v)
+		c.notifyEvicted(k, v)
+	}
+}
+
+// 仅当键存在、未过期（且不是负缓存项目）且eq对当前值返回true时删除该项目，并返回是否删除
+// 检查和删除在同一个写锁内完成，因此不会误删其他写入者刚替换的新值
+// eq在持有写锁时运行，因此不能回调CachePro的方法
+func (c *CachePro[T]) CompareAndDelete(k string, eq func(current T) bool) bool {
+	c.mu.Lock()
+	item, found := c.items[k]
+	if !found || item.Negative || (item.Expiration > 0 && c.clock.Now().UnixNano() > item.Expiration) || !eq(item.Object) {
+		c.mu.Unlock()
+		return false
+	}
+	v, evicted := c.delete(k)
+	c.mu.Unlock()
+	if evicted {
+		c.notifyEvicted(k, v)
+	}
+	return true
+}
+
+// 在同一个写锁内从CachePro删除keys中的所有项目，不存在的键会被忽略
+// onEvicted在释放锁之后才调用
+func (c *CachePro[T]) DeleteMany(keys []string) {
+	var evictedItems []keyAndValuePro
+	c.mu.Lock()
+	for _, k := range keys {
+		v, evicted := c.delete(k)
+		if evicted {
+			evictedItems = append(evictedItems, keyAndValuePro{k, v})
+		}
+	}
+	c.mu.Unlock()
+	for _, v := range evictedItems {
+		c.notifyEvicted(v.key, v.value)
+	}
+}
+
+// 在同一个写锁内删除所有使pred返回true的项目（包括已过期但尚未清理的项目），并返回删除的数量
+// pred在持有写锁时运行，因此不能回调CachePro的方法；onEvicted在释放锁之后才调用
+func (c *CachePro[T]) DeleteMatching(pred func(key string) bool) int {
+	var evictedItems []keyAndValuePro
+	n := 0
+	c.mu.Lock()
+	for k := range c.items {
+		if !pred(k) {
+			continue
+		}
+		v, evicted := c.delete(k)
+		if evicted {
+			evictedItems = append(evictedItems, keyAndValuePro{k, v})
+		}
+		n++
+	}
+	c.mu.Unlock()
+	for _, v := range evictedItems {
+		c.notifyEvicted(v.key, v.value)
+	}
+	return n
+}
+
+// 在同一个写锁内将所有使pred返回true的未过期项目立即标记为已过期，并返回标记的数量
+// 与DeleteMatching不同，这些项目会留在CachePro中，由清理程序或DeleteExpired删除，
+// 届时会像正常过期一样触发OnExpired。pred在持有写锁时运行，因此不能回调CachePro的方法
+func (c *CachePro[T]) ExpireMatching(pred func(key string) bool) int {
+	c.mustBeOpen()
+	n := 0
+	c.mu.Lock()
+	now := c.clock.Now().UnixNano()
+	for k, v := range c.items {
+		if (v.Expiration > 0 && now > v.Expiration) || !pred(k) {
+			continue
+		}
+		v.Expiration = max(now-1, 1)
+		// 清除硬过期时间，否则项目在硬过期之前仍可以通过GetStale读取，也不会被清理
+		v.HardExpiration = 0
+		c.put(k, v)
+		n++
+	}
+	c.mu.Unlock()
+	return n
+}
+
+// 在同一个写锁内对每个未过期的项目调用f，用f返回的新值替换旧值（保留过期时间），
+// 或者在keep为false时删除该项目。f在持有写锁时运行，因此必须足够快，并且不能回调CachePro的方法
+func (c *CachePro[T]) UpdateAll(f func(key string, old T) (new T, keep bool)) {
+	c.mustBeOpen()
+	var evictedItems []keyAndValuePro
+	c.mu.Lock()
+	now := c.clock.Now().UnixNano()
+	for k, v := range c.items {
+		if v.Negative || (v.Expiration > 0 && now > v.Expiration) {
+			continue
+		}
+		x, keep := f(k, v.Object)
+		if !keep {
+			ov, evicted := c.delete(k)
+			if evicted {
+				evictedItems = append(evictedItems, keyAndValuePro{k, ov})
+			}
+			continue
+		}
+		v.Object = x
+		v.Version = 0
+		c.put(k, v)
+	}
+	c.mu.Unlock()
+	for _, v := range evictedItems {
+		c.notifyEvicted(v.key, v.value)
 	}
 }
 
 func (c *cachePro[T]) delete(k string) (interface{}, bool) {
 	if c.onEvicted != nil {
 		if v, found := c.items[k]; found {
-			if c.delFunc != nil {
-				c.delFunc(v.Object)
-			}
-			delete(c.items, k)
+			c.release(v)
+			c.remove(k)
+			c.evictions.Add(1)
 			return v.Object, true
 		}
 	}
 	if v, ok := c.items[k]; ok {
-		if c.delFunc != nil {
-			c.delFunc(v.Object)
+		c.release(v)
+		c.remove(k)
+		c.evictions.Add(1)
+	}
+	return nil, false
+}
+
+// 将不再被CachePro持有的项目的值传给delFunc，负缓存项目没有值，不会调用。调用方必须持有写锁
+// delFunc是释放值所持有资源的唯一入口：删除、过期、驱逐、覆盖和清空都会对每个被移除的值调用一次
+func (c *cachePro[T]) release(item ItemPro[T]) {
+	if c.delFunc != nil && !item.Negative {
+		c.delFunc(item.Object)
+	}
+}
+
+// 如果CachePro已关闭则panic。写入方法必须在获取写锁之前调用，
+// 在持有写锁时panic会使写锁永远不被释放，之后的所有调用都会死锁
+func (c *cachePro[T]) mustBeOpen() {
+	if c.closed.Load() {
+		panic("cache: write to a closed CachePro")
+	}
+}
+
+// 与put相同，但键k已有的项目会被视为移除并传给delFunc。调用方必须持有写锁
+// 用于以调用方提供的新值替换旧值的写入；Compute、Increment等从旧值派生新值，
+// 以及只修改过期时间的更新应使用put，以免释放仍在使用的值
+func (c *cachePro[T]) overwrite(k string, item ItemPro[T]) {
+	if old, found := c.items[k]; found {
+		c.release(old)
+	}
+	c.put(k, item)
+}
+
+// 写入一个项目，调用方必须持有写锁
+// 所有对items的写入都应经过此方法，以便记录操作日志、转发给镜像目标等
+func (c *cachePro[T]) put(k string, item ItemPro[T]) {
+	// 写入方法在获取写锁之前已经调用了mustBeOpen，这里只会遇到与Close并发的写入：
+	// 像Close删除的其他项目一样释放它，而不是在持有写锁时panic
+	if c.closed.Load() {
+		c.release(item)
+		return
+	}
+	// 新构造的项目分配新的版本号；从存档、日志等载入的项目保留原有的版本号
+	if item.Version == 0 {
+		c.version++
+		item.Version = c.version
+	} else {
+		c.version = max(c.version, item.Version)
+	}
+	if c.trackAccess {
+		item.LastAccess = c.clock.Now().UnixNano()
+	}
+	if c.policy != nil {
+		c.trackPut(k, item)
+	}
+	c.items[k] = item
+	if c.opLog != nil {
+		c.logOp(opSet, k, item)
+	}
+	if c.mirror != nil {
+		c.mirror.set(k, item, c.clock.Now().UnixNano())
+	}
+	if c.writeBehind != nil && !item.Negative {
+		c.writeBehind.mark(k, item.Object)
+	}
+	if len(c.watchers) > 0 {
+		c.emit(k, EventSet)
+	}
+	if len(c.waiters) > 0 && !item.Negative {
+		c.wake(k)
+	}
+}
+
+// 移除一个项目（不调用delFunc），调用方必须持有写锁
+// 所有对items的删除都应经过此方法，以便记录操作日志、转发给镜像目标等
+func (c *cachePro[T]) remove(k string) {
+	if c.policy != nil {
+		c.trackRemove(k)
+	}
+	if len(c.watchers) > 0 {
+		if item, found := c.items[k]; found {
+			if c.expired(item) {
+				c.emit(k, EventExpired)
+			} else {
+				c.emit(k, EventDeleted)
+			}
 		}
 	}
 	delete(c.items, k)
-	return nil, false
+	if c.opLog != nil {
+		c.logOp(opDelete, k, ItemPro[T]{})
+	}
+	if c.mirror != nil {
+		c.mirror.delete(k)
+	}
+}
+
+// 在同一个写锁内将oldKey的项目（值和精确的过期时间）移动到newKey，并删除oldKey
+// 如果newKey已存在则覆盖它（与Set一样，被覆盖的值会传给delFunc）
+// 如果oldKey不存在、已过期或是负缓存项目则返回false
+func (c *CachePro[T]) Rename(oldKey, newKey string) bool {
+	c.mustBeOpen()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	item, found := c.items[oldKey]
+	if !found || item.Negative || (item.Expiration > 0 && c.clock.Now().UnixNano() > item.Expiration) {
+		return false
+	}
+	if oldKey == newKey {
+		return true
+	}
+	item.Version = 0
+	c.overwrite(newKey, item)
+	c.remove(oldKey)
+	return true
+}
+
+// 替换全部项目，调用方必须持有写锁
+func (c *cachePro[T]) resetItems(m map[string]ItemPro[T]) {
+	if len(c.watchers) > 0 {
+		for k := range c.items {
+			c.emit(k, EventDeleted)
+		}
+		for k := range m {
+			c.emit(k, EventSet)
+		}
+	}
+	old := c.items
+	c.items = m
+	// 载入的项目可能带有比当前计数器更大的版本号，避免之后分配重复的版本号
+	for _, v := range m {
+		c.version = max(c.version, v.Version)
+	}
+	if c.policy != nil {
+		c.trackReset(old)
+	}
 }
 
 type keyAndValuePro struct {
@@ -207,14 +926,68 @@ type keyAndValuePro struct {
 	value interface{}
 }
 
+type keyAndItemPro[T any] struct {
+	key  string
+	item ItemPro[T]
+}
+
 // 从CachePro删除所有已过期的项目
 func (c *CachePro[T]) DeleteExpired() {
 	var evictedItems []keyAndValuePro
-	now := time.Now().UnixNano()
+	var expiredItems []keyAndItemPro[T]
+	now := c.clock.Now().UnixNano()
+	c.mu.Lock()
+	onExpired := c.onExpired
+	for k, v := range c.items {
+		// "Inlining" of expired
+		if v.Expiration > 0 && now > v.Expiration && now > v.HardExpiration {
+			ov, evicted := c.delete(k)
+			if evicted {
+				evictedItems = append(evictedItems, keyAndValuePro{k, ov})
+			}
+			if onExpired != nil && !v.Negative {
+				expiredItems = append(expiredItems, keyAndItemPro[T]{k, v})
+			}
+		}
+	}
+	c.mu.Unlock()
+	for _, v := range evictedItems {
+		c.notifyEvicted(v.key, v.value)
+	}
+	for _, v := range expiredItems {
+		onExpired(v.key, v.item.Object)
+	}
+}
+
+// 设置一个（可选的）校验函数，清理程序每运行every次就对所有未过期的项目调用一次该函数，
+// 返回false的项目即使未过期也会被驱逐（并触发onEvicted）。every小于1时按1处理
+// 校验函数在持有写锁时运行，因此应尽可能快，且不能回调CachePro的方法
+// 设置为nil以禁用
+func (c *CachePro[T]) SetValidator(f func(key string, value T) bool, every int) {
+	if every < 1 {
+		every = 1
+	}
+	c.mu.Lock()
+	c.validator = f
+	c.validateEvery = every
+	c.mu.Unlock()
+}
+
+// 立即使用校验函数检查所有未过期的项目，驱逐校验失败的项目。如果未设置校验函数则不执行任何操作
+func (c *CachePro[T]) DeleteInvalid() {
+	var evictedItems []keyAndValuePro
+	now := c.clock.Now().UnixNano()
 	c.mu.Lock()
+	if c.validator == nil {
+		c.mu.Unlock()
+		return
+	}
 	for k, v := range c.items {
 		// "Inlining" of expired
 		if v.Expiration > 0 && now > v.Expiration {
+			continue
+		}
+		if !c.validator(k, v.Object) {
 			ov, evicted := c.delete(k)
 			if evicted {
 				evictedItems = append(evictedItems, keyAndValuePro{k, ov})
@@ -223,10 +996,73 @@ func (c *CachePro[T]) DeleteExpired() {
 	}
 	c.mu.Unlock()
 	for _, v := range evictedItems {
-		c.onEvicted(v.key, v.value)
+		c.notifyEvicted(v.key, v.value)
 	}
 }
 
+// 由清理程序在每次运行后调用，按照配置的频率执行DeleteInvalid
+func (c *CachePro[T]) tickValidator() {
+	c.mu.RLock()
+	f, every := c.validator, c.validateEvery
+	c.mu.RUnlock()
+	if f == nil {
+		return
+	}
+	c.validateTicks++
+	if c.validateTicks%every == 0 {
+		c.DeleteInvalid()
+	}
+}
+
+// 异步执行onEvicted时使用的队列数。同一个键总是进入同一个队列，因此其回调按驱逐顺序执行
+const evictionQueueCount = 8
+
+type evictionQueues [evictionQueueCount]*serialQueue
+
+// 调用onEvicted。调用方不能持有锁
+func (c *cachePro[T]) notifyEvicted(k string, v interface{}) {
+	f := c.onEvicted
+	if f == nil {
+		return
+	}
+	if qs := c.evictQueues.Load(); qs != nil {
+		qs[djb33(0, k)%evictionQueueCount].push(func() {
+			f(k, v)
+		})
+		return
+	}
+	f(k, v)
+}
+
+// 等待所有已提交的异步onEvicted回调执行完毕
+func (c *cachePro[T]) waitEvictions() {
+	if qs := c.evictQueues.Load(); qs != nil {
+		for _, q := range qs {
+			q.wait()
+		}
+	}
+}
+
+// 设置是否在后台goroutine池中异步执行onEvicted，而不是在驱逐项目的调用中同步执行
+// 启用后，同一个键的回调仍按驱逐顺序执行；Flush会等待所有已提交的回调执行完毕
+// 回调中不能调用Flush，否则会死锁
+func (c *CachePro[T]) AsyncEvictionCallbacks(enabled bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !enabled {
+		c.evictQueues.Store(nil)
+		return
+	}
+	if c.evictQueues.Load() != nil {
+		return
+	}
+	qs := &evictionQueues{}
+	for i := range qs {
+		qs[i] = newSerialQueue(c.workers, 0)
+	}
+	c.evictQueues.Store(qs)
+}
+
 // 设置一个（可选的）函数，当项目从CachePro中驱逐时调用该函数（包括手动删除时，但不包括覆盖时）
 // 设置为nil以禁用
 func (c *CachePro[T]) OnEvicted(f func(string, interface{})) {
@@ -235,23 +1071,63 @@ func (c *CachePro[T]) OnEvicted(f func(string, interface{})) {
 	c.mu.Unlock()
 }
 
+// 设置（或替换）NewPro等构造函数传入的delFunc。之后每个被移除的值都会以该值调用一次f：
+// 手动删除、过期清理、内存上限驱逐、被Set等写入覆盖以及Flush都会调用。
+// Compute、Increment等在原值基础上更新以及只修改过期时间的方法不会调用。f在持有写锁时同步调用
+// （Flush除外），因此不能回调CachePro的方法。设置为nil以禁用
+func (c *CachePro[T]) SetDelFunc(f func(T)) {
+	c.mu.Lock()
+	c.delFunc = f
+	c.mu.Unlock()
+}
+
 // 将CachePro的项写入io.Writer（使用Gob编码）
 //
 // 注意：此方法已弃用，推荐使用c.Items()和NewFrom()（参见NewFrom()的文档）
-func (c *CachePro[T]) Save(w io.Writer) (err error) {
-	enc := gob.NewEncoder(w)
-	defer func() {
-		if x := recover(); x != nil {
-			err = fmt.Errorf("Error registering item types with Gob library")
-		}
-	}()
+//
+// 当T是接口类型时，Save会注册遇到的每个具体类型，但同名类型冲突等注册错误会导致保存失败，
+// 且Load时也需要先注册相同的类型，因此建议在程序启动时使用RegisterGobTypes预先注册
+func (c *CachePro[T]) Save(w io.Writer) error {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
-	for _, v := range c.items {
-		gob.Register(v.Object)
+	return encodeItemsPro(w, c.items)
+}
+
+// 注册items中各个值的具体类型，然后以Gob编码将items写入w
+func encodeItemsPro[T any](w io.Writer, items map[string]ItemPro[T]) error {
+	for _, v := range items {
+		if err := registerGobType(v.Object); err != nil {
+			return fmt.Errorf("Error registering item types with Gob library: %w", err)
+		}
 	}
-	err = enc.Encode(&c.items)
-	return
+	return gob.NewEncoder(w).Encode(&items)
+}
+
+// RegisterGobTypes 向gob注册samples的具体类型，使保存在接口类型（例如CachePro[interface{}]）中的
+// 这些类型的值可以通过Save和Load编码和解码。应在程序启动时调用一次，
+// 与gob.Register一样，为同一个名称注册不同的类型会导致panic
+func RegisterGobTypes(samples ...interface{}) {
+	for _, v := range samples {
+		gob.Register(v)
+	}
+}
+
+// 向gob注册x的具体类型，将gob.Register的panic转换为错误。nil值会被忽略
+func registerGobType(x interface{}) (err error) {
+	if x == nil {
+		return nil
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			if e, ok := r.(error); ok {
+				err = e
+			} else {
+				err = fmt.Errorf("%v", r)
+			}
+		}
+	}()
+	gob.Register(x)
+	return nil
 }
 
 // 将CachePro的项保存到给定文件名，如果文件不存在则创建，如果存在则覆盖
@@ -267,43 +1143,383 @@ func (c *CachePro[T]) SaveFile(fname string) error {
 		fp.Close()
 		return err
 	}
-	return fp.Close()
+	return fp.Close()
+}
+
+// 与SaveFile相同，但先写入同一目录下的临时文件，fsync之后再重命名为fname，
+// 因此即使写入中途出错或进程崩溃，fname也要么是原有的文件，要么是完整的新快照（POSIX上重命名是原子的）
+// 出错时临时文件会被删除
+func (c *CachePro[T]) SaveFileAtomic(fname string) (err error) {
+	fp, err := os.CreateTemp(filepath.Dir(fname), filepath.Base(fname)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			fp.Close()
+			os.Remove(fp.Name())
+		}
+	}()
+	if err = c.Save(fp); err != nil {
+		return err
+	}
+	if err = fp.Sync(); err != nil {
+		return err
+	}
+	if err = fp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(fp.Name(), fname)
+}
+
+// 与SaveFile相同，但使用gzip压缩写入的快照，适合值的重复度较高、快照文件较大的情况
+// 使用LoadFileCompressed读取
+func (c *CachePro[T]) SaveFileCompressed(fname string) error {
+	fp, err := os.Create(fname)
+	if err != nil {
+		return err
+	}
+	zw := gzip.NewWriter(fp)
+	if err = c.Save(zw); err != nil {
+		zw.Close()
+		fp.Close()
+		return err
+	}
+	// 必须先关闭gzip.Writer写出剩余的数据和尾部，再关闭文件，否则快照会被截断
+	if err = zw.Close(); err != nil {
+		fp.Close()
+		return err
+	}
+	return fp.Close()
+}
+
+// 从io.Reader添加（Gob序列化的）CachePro项，排除当前CachePro中已存在（且未过期）的键
+//
+// 注意：此方法已弃用，推荐使用c.Items()和NewFrom()（参见NewFrom()的文档）
+func (c *CachePro[T]) Load(r io.Reader) error {
+	c.mustBeOpen()
+	dec := gob.NewDecoder(r)
+	items := map[string]ItemPro[T]{}
+	err := dec.Decode(&items)
+	if err == nil {
+		c.merge(items)
+	}
+	return err
+}
+
+// 将items合并到CachePro中，跳过当前已存在且未过期的键
+func (c *cachePro[T]) merge(items map[string]ItemPro[T]) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for k, v := range items {
+		ov, found := c.items[k]
+		if !found || c.expired(ov) {
+			c.overwrite(k, v)
+		}
+	}
+}
+
+// 与Load相同，从io.Reader添加（Gob序列化的）CachePro项，但键冲突时保留过期时间较晚的项目
+// （永不过期视为最晚，相同时保留当前的项目）。适用于节点之间交换快照时让最新的TTL胜出
+func (c *CachePro[T]) LoadMergeNewer(r io.Reader) error {
+	c.mustBeOpen()
+	items := map[string]ItemPro[T]{}
+	if err := gob.NewDecoder(r).Decode(&items); err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for k, v := range items {
+		ov, found := c.items[k]
+		if !found || c.expired(ov) || expiresLater(v, ov) {
+			c.overwrite(k, v)
+		}
+	}
+	return nil
+}
+
+// 如果a比b过期得晚则返回true，永不过期视为最晚
+func expiresLater[T any](a, b ItemPro[T]) bool {
+	if a.Expiration <= 0 {
+		return b.Expiration > 0
+	}
+	return b.Expiration > 0 && a.Expiration > b.Expiration
+}
+
+// Merge 键冲突时的处理方式
+type ConflictPolicy int
+
+const (
+	// 保留当前CachePro中的项目
+	KeepMine ConflictPolicy = iota
+	// 使用另一个CachePro中的项目
+	KeepTheirs
+	// 保留过期时间较晚的项目（永不过期视为最晚，相同时保留当前的项目）
+	KeepLaterExpiration
+)
+
+// Merge 将other中所有未过期的项目（值和精确的过期时间）复制到CachePro中，
+// 键在当前CachePro中已存在且未过期时按conflict处理。被覆盖的值会传给delFunc，other不受影响
+//
+// 复制期间同时持有当前CachePro的写锁和other的读锁。两个锁总是按CachePro的内存地址从低到高获取，
+// 因此a.Merge(b, ...)和b.Merge(a, ...)并发执行也不会死锁。other与c相同时不执行任何操作
+// 有内存上限时，合并之后、释放写锁之前会像Set一样驱逐项目直到总大小不超过上限，合并进来的项目也可能被驱逐
+func (c *CachePro[T]) Merge(other *CachePro[T], conflict ConflictPolicy) {
+	c.mustBeOpen()
+	if other.cachePro == c.cachePro {
+		return
+	}
+	if uintptr(unsafe.Pointer(c.cachePro)) < uintptr(unsafe.Pointer(other.cachePro)) {
+		c.mu.Lock()
+		other.mu.RLock()
+	} else {
+		other.mu.RLock()
+		c.mu.Lock()
+	}
+	for k, v := range other.items {
+		if other.expired(v) {
+			continue
+		}
+		if ov, found := c.items[k]; found && !c.expired(ov) {
+			switch conflict {
+			case KeepMine:
+				continue
+			case KeepLaterExpiration:
+				if !expiresLater(v, ov) {
+					continue
+				}
+			}
+		}
+		v.Version = 0
+		c.overwrite(k, v)
+	}
+	other.mu.RUnlock()
+	c.unlockAndEvict()
+}
+
+// 从给定文件名加载并添加CachePro项，排除当前CachePro中已存在的键
+//
+// 注意：此方法已弃用，推荐使用c.Items()和NewFrom()（参见NewFrom()的文档）
+func (c *CachePro[T]) LoadFile(fname string) error {
+	fp, err := os.Open(fname)
+	if err != nil {
+		return err
+	}
+	err = c.Load(fp)
+	if err != nil {
+		fp.Close()
+		return err
+	}
+	return fp.Close()
+}
+
+// SnapshotSize 返回Save此时会写出的字节数，编码结果直接丢弃，不会在内存中保留快照
+// 可用于在保存之前决定是否需要压缩
+func (c *CachePro[T]) SnapshotSize() (int64, error) {
+	w := &countingWriter{w: io.Discard}
+	if err := c.Save(w); err != nil {
+		return 0, err
+	}
+	return w.n, nil
+}
+
+// 统计写入字节数的io.Writer
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (cw *countingWriter) Write(p []byte) (int, error) {
+	n, err := cw.w.Write(p)
+	cw.n += int64(n)
+	return n, err
+}
+
+// 与LoadFile相同，但读取SaveFileCompressed写入的gzip压缩快照
+func (c *CachePro[T]) LoadFileCompressed(fname string) error {
+	fp, err := os.Open(fname)
+	if err != nil {
+		return err
+	}
+	zr, err := gzip.NewReader(fp)
+	if err != nil {
+		fp.Close()
+		return err
+	}
+	err = c.Load(zr)
+	if err == nil {
+		err = zr.Close()
+	}
+	if err != nil {
+		fp.Close()
+		return err
+	}
+	return fp.Close()
+}
+
+// 将CachePro中未过期的项序列化（使用Gob编码）为字节切片并返回，不涉及任何文件
+// 与Save不同，已过期但尚未清理的项目不会被序列化
+func (c *CachePro[T]) Marshal() ([]byte, error) {
+	buf := &bytes.Buffer{}
+	if err := encodeItemsPro(buf, c.Items()); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// 从（Gob序列化的）字节切片中恢复CachePro项。与Load的合并行为不同，
+// 解码成功后会完全替换CachePro当前的内容；解码失败时CachePro保持不变
+func (c *CachePro[T]) Unmarshal(data []byte) error {
+	items := map[string]ItemPro[T]{}
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&items); err != nil {
+		return err
+	}
+	c.mu.Lock()
+	old := c.items
+	c.resetItems(items)
+	if c.opLog != nil {
+		c.logOp(opFlush, "", ItemPro[T]{})
+		for k, v := range items {
+			c.logOp(opSet, k, v)
+		}
+	}
+	c.mu.Unlock()
+	// 被替换的项目与Flush删除的项目一样传给delFunc
+	for _, v := range old {
+		c.release(v)
+	}
+	return nil
+}
+
+// 将CachePro中所有未过期项目的键及其过期时间（使用Gob编码）写入io.Writer，不包括值
+// 用于在节点之间低成本地比较键集合，配合LoadIndex和MissingKeys使用
+func (c *CachePro[T]) SaveIndex(w io.Writer) error {
+	c.mu.RLock()
+	index := make(map[string]int64, len(c.items))
+	now := c.clock.Now().UnixNano()
+	for k, v := range c.items {
+		// "Inlining" of Expired
+		if v.Expiration > 0 {
+			if now > v.Expiration {
+				continue
+			}
+		}
+		index[k] = v.Expiration
+	}
+	c.mu.RUnlock()
+	return gob.NewEncoder(w).Encode(index)
+}
+
+// 从io.Reader读取由SaveIndex写入的键和过期时间
+func LoadIndex(r io.Reader) (map[string]int64, error) {
+	index := map[string]int64{}
+	err := gob.NewDecoder(r).Decode(&index)
+	return index, err
+}
+
+// 返回index中当前CachePro缺少或版本较旧的键（按字典序排列）
+// 如果本地不存在该键、已过期，或者本地的过期时间早于index中的过期时间（0表示永不过期，视为最晚），
+// 则认为该键缺失
+func (c *CachePro[T]) MissingKeys(index map[string]int64) []string {
+	var missing []string
+	c.mu.RLock()
+	now := c.clock.Now().UnixNano()
+	for k, e := range index {
+		v, found := c.items[k]
+		if !found || (v.Expiration > 0 && now > v.Expiration) {
+			missing = append(missing, k)
+			continue
+		}
+		if v.Expiration > 0 && (e == 0 || e > v.Expiration) {
+			missing = append(missing, k)
+		}
+	}
+	c.mu.RUnlock()
+	sort.Strings(missing)
+	return missing
 }
 
-// 从io.Reader添加（Gob序列化的）CachePro项，排除当前CachePro中已存在（且未过期）的键
+// 将CachePro的项以JSON编码写入io.Writer，保留每个项目的Object和Expiration
 //
-// 注意：此方法已弃用，推荐使用c.Items()和NewFrom()（参见NewFrom()的文档）
-func (c *CachePro[T]) Load(r io.Reader) error {
-	dec := gob.NewDecoder(r)
-	items := map[string]ItemPro[T]{}
-	err := dec.Decode(&items)
+// 与Save不同，此方法不需要注册具体类型，但要求T可以被encoding/json序列化
+func (c *CachePro[T]) SaveJSON(w io.Writer) error {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return json.NewEncoder(w).Encode(c.items)
+}
+
+// 从io.Reader添加（JSON序列化的）CachePro项，排除当前CachePro中已存在（且未过期）的键
+// 如果数据流中同一个键出现多次，按照SetDuplicateKeyPolicy设置的策略处理
+func (c *CachePro[T]) LoadJSON(r io.Reader) error {
+	c.mu.RLock()
+	policy := c.dupPolicy
+	c.mu.RUnlock()
+	items, err := decodeJSONItems[T](r, policy)
 	if err == nil {
-		c.mu.Lock()
-		defer c.mu.Unlock()
-		for k, v := range items {
-			ov, found := c.items[k]
-			if !found || ov.Expired() {
-				c.items[k] = v
-			}
-		}
+		c.merge(items)
 	}
 	return err
 }
 
-// 从给定文件名加载并添加CachePro项，排除当前CachePro中已存在的键
-//
-// 注意：此方法已弃用，推荐使用c.Items()和NewFrom()（参见NewFrom()的文档）
-func (c *CachePro[T]) LoadFile(fname string) error {
-	fp, err := os.Open(fname)
+// 导入数据流中同一个键重复出现时的处理策略
+type DuplicateKeyPolicy int
+
+const (
+	// 后出现的项目覆盖先出现的项目（默认）
+	DuplicateLastWins DuplicateKeyPolicy = iota
+	// 保留先出现的项目，忽略之后重复的项目
+	DuplicateFirstWins
+	// 遇到重复的键时返回错误，不导入任何项目
+	DuplicateError
+)
+
+// 设置LoadJSON遇到数据流中重复的键时的处理策略
+// Gob编码的映射中不会出现重复的键，因此该策略不影响Load
+func (c *CachePro[T]) SetDuplicateKeyPolicy(p DuplicateKeyPolicy) {
+	c.mu.Lock()
+	c.dupPolicy = p
+	c.mu.Unlock()
+}
+
+// 逐个读取JSON对象中的键值对，以便按照策略处理重复的键
+// （json.Decoder直接解码到映射时会静默地让后出现的值覆盖先出现的值）
+func decodeJSONItems[T any](r io.Reader, policy DuplicateKeyPolicy) (map[string]ItemPro[T], error) {
+	items := map[string]ItemPro[T]{}
+	dec := json.NewDecoder(r)
+	tok, err := dec.Token()
 	if err != nil {
-		return err
+		return nil, err
 	}
-	err = c.Load(fp)
-	if err != nil {
-		fp.Close()
-		return err
+	if tok == nil {
+		return items, nil
 	}
-	return fp.Close()
+	if d, ok := tok.(json.Delim); !ok || d != '{' {
+		return nil, fmt.Errorf("Expected a JSON object, got %v", tok)
+	}
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		k := tok.(string)
+		var v ItemPro[T]
+		if err := dec.Decode(&v); err != nil {
+			return nil, err
+		}
+		if _, dup := items[k]; dup {
+			switch policy {
+			case DuplicateFirstWins:
+				continue
+			case DuplicateError:
+				return nil, fmt.Errorf("Duplicate key %s in stream", k)
+			}
+		}
+		items[k] = v
+	}
+	if _, err := dec.Token(); err != nil {
+		return nil, err
+	}
+	return items, nil
 }
 
 // 将所有未过期的CachePro项复制到新映射中并返回
@@ -311,7 +1527,7 @@ func (c *CachePro[T]) Items() map[string]ItemPro[T] {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
 	m := make(map[string]ItemPro[T], len(c.items))
-	now := time.Now().UnixNano()
+	now := c.clock.Now().UnixNano()
 	for k, v := range c.items {
 		// "Inlining" of Expired
 		if v.Expiration > 0 {
@@ -324,6 +1540,65 @@ func (c *CachePro[T]) Items() map[string]ItemPro[T] {
 	return m
 }
 
+// 返回CachePro中所有未过期的键（不包括负缓存项目），顺序不确定
+func (c *CachePro[T]) Keys() []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	keys := make([]string, 0, len(c.items))
+	now := c.clock.Now().UnixNano()
+	for k, v := range c.items {
+		if (v.Expiration > 0 && now > v.Expiration) || v.Negative {
+			continue
+		}
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+// 对CachePro中每个未过期的项目调用f，直到f返回false，顺序不确定
+// f在持有读锁时运行，因此不能修改CachePro
+func (c *CachePro[T]) Range(f func(k string, v T) bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	now := c.clock.Now().UnixNano()
+	for k, v := range c.items {
+		if (v.Expiration > 0 && now > v.Expiration) || v.Negative {
+			continue
+		}
+		if !f(k, v.Object) {
+			return
+		}
+	}
+}
+
+// ItemsFiltered 与Items相同，但只复制pred返回true的未过期项目
+// pred在持有读锁时运行，因此不能修改CachePro
+func (c *CachePro[T]) ItemsFiltered(pred func(key string, item ItemPro[T]) bool) map[string]ItemPro[T] {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	m := make(map[string]ItemPro[T])
+	now := c.clock.Now().UnixNano()
+	for k, v := range c.items {
+		// "Inlining" of Expired
+		if v.Expiration > 0 {
+			if now > v.Expiration {
+				continue
+			}
+		}
+		if pred(k, v) {
+			m[k] = v
+		}
+	}
+	return m
+}
+
+// Clone 返回CachePro当前状态的独立副本：包含所有未过期的项目（值按赋值复制）和相同的默认过期时间，
+// 但使用自己的映射和锁，之后对任意一方的修改都不会影响另一方
+// 副本不运行清理程序（可以通过SetCleanupInterval启动），也不继承delFunc、onEvicted等回调
+func (c *CachePro[T]) Clone() *CachePro[T] {
+	return newCacheProWithJanitor[T](c.defaultExpiration, 0, c.Items(), nil, withClock[T](c.clock))
+}
+
 // 返回CachePro中的项目数。这可能包括已过期但尚未清理的项目
 func (c *CachePro[T]) ItemCount() int {
 	c.mu.RLock()
@@ -332,16 +1607,119 @@ func (c *CachePro[T]) ItemCount() int {
 	return n
 }
 
-// 从CachePro中删除所有项目
+// 返回CachePro中未过期的项目数。与ItemCount不同，不包括已过期但尚未清理的项目
+func (c *CachePro[T]) ItemCountLive() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	n := 0
+	now := c.clock.Now().UnixNano()
+	for _, v := range c.items {
+		// "Inlining" of Expired
+		if v.Expiration > 0 {
+			if now > v.Expiration {
+				continue
+			}
+		}
+		n++
+	}
+	return n
+}
+
+// 返回在接下来的d时间内将要过期的项目数，即过期时间晚于现在且不晚于现在加d的项目
+// 永不过期和已经过期的项目都不计入，可用于预估即将到来的重新加载压力
+func (c *CachePro[T]) CountExpiringWithin(d time.Duration) int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	n := 0
+	now := c.clock.Now()
+	from, to := now.UnixNano(), now.Add(d).UnixNano()
+	for _, v := range c.items {
+		if v.Expiration > from && v.Expiration <= to {
+			n++
+		}
+	}
+	return n
+}
+
+// Shrink 删除所有已过期的项目（与DeleteExpired一样调用回调），然后把剩余的项目复制到一个按当前大小分配的新映射中
+// Go的映射在删除元素后不会缩小，大量删除或过期之后调用Shrink可以释放旧映射占用的内存。
+// 复制期间持有写锁，耗时与剩余项目数成正比
+func (c *CachePro[T]) Shrink() {
+	c.DeleteExpired()
+	c.mu.Lock()
+	items := make(map[string]ItemPro[T], len(c.items))
+	for k, v := range c.items {
+		items[k] = v
+	}
+	c.items = items
+	c.mu.Unlock()
+}
+
+// 估算的每个映射条目的额外开销（哈希桶中的tophash、溢出指针等）
+const mapEntryOverhead = 8
+
+// 估算单个条目占用的字节数，包括键字符串（头部和内容）、映射开销、
+// ItemPro中除值以外的字段，以及由sizeOf估算的值大小
+// 如果键不存在或已过期则返回(0, false)
+func (c *CachePro[T]) EstimatedEntryBytes(k string, sizeOf func(T) int64) (int64, bool) {
+	c.mu.RLock()
+	item, found := c.items[k]
+	c.mu.RUnlock()
+	if !found || (item.Expiration > 0 && c.clock.Now().UnixNano() > item.Expiration) {
+		return 0, false
+	}
+	n := int64(len(k)) + int64(unsafe.Sizeof(k)) + mapEntryOverhead
+	n += int64(unsafe.Sizeof(item) - unsafe.Sizeof(item.Object))
+	n += sizeOf(item.Object)
+	return n, true
+}
+
+// 从CachePro中删除所有项目，并在释放写锁之后对每个被删除的值调用delFunc
+// 不会调用onEvicted，需要时请使用FlushWithCallback
 func (c *CachePro[T]) Flush() {
 	c.mu.Lock()
-	c.items = map[string]ItemPro[T]{}
+	items := c.items
+	delFunc := c.delFunc
+	c.resetItems(map[string]ItemPro[T]{})
+	if c.opLog != nil {
+		c.logOp(opFlush, "", ItemPro[T]{})
+	}
+	c.mu.Unlock()
+	if delFunc != nil {
+		for _, v := range items {
+			if !v.Negative {
+				delFunc(v.Object)
+			}
+		}
+	}
+	c.waitEvictions()
+}
+
+// FlushWithCallback 与Flush相同，但在释放写锁之后还会对每个被删除的项目调用onEvicted
+// 已过期但尚未清理的项目也会触发回调
+func (c *CachePro[T]) FlushWithCallback() {
+	c.mu.Lock()
+	items := c.items
+	delFunc := c.delFunc
+	c.resetItems(map[string]ItemPro[T]{})
+	if c.opLog != nil {
+		c.logOp(opFlush, "", ItemPro[T]{})
+	}
 	c.mu.Unlock()
+	c.evictions.Add(uint64(len(items)))
+	for k, v := range items {
+		if delFunc != nil && !v.Negative {
+			delFunc(v.Object)
+		}
+		c.notifyEvicted(k, v.Object)
+	}
+	c.waitEvictions()
 }
 
 type janitorPro[T any] struct {
 	Interval time.Duration
 	stop     chan bool
+	reset    chan time.Duration
 }
 
 func (j *janitorPro[T]) Run(c *CachePro[T]) {
@@ -350,6 +1728,9 @@ func (j *janitorPro[T]) Run(c *CachePro[T]) {
 		select {
 		case <-ticker.C:
 			c.DeleteExpired()
+			c.tickValidator()
+		case d := <-j.reset:
+			ticker.Reset(d)
 		case <-j.stop:
 			ticker.Stop()
 			return
@@ -358,18 +1739,98 @@ func (j *janitorPro[T]) Run(c *CachePro[T]) {
 }
 
 func stopJanitorPro[T any](c *CachePro[T]) {
-	c.janitor.stop <- true
+	if c.closed.Load() {
+		return
+	}
+	if c.closeOnFinalize {
+		c.Close()
+		return
+	}
+	if c.janitor != nil {
+		c.janitor.stop <- true
+	}
+	if c.writeBehind != nil {
+		close(c.writeBehind.stop)
+	}
 }
 
 func runJanitorPro[T any](c *cachePro[T], ci time.Duration) {
 	j := &janitorPro[T]{
 		Interval: ci,
 		stop:     make(chan bool),
+		reset:    make(chan time.Duration),
 	}
 	c.janitor = j
+	c.cleanupInterval = ci
 	go j.Run(&CachePro[T]{c})
 }
 
+// 在运行时修改清理间隔。如果d小于等于0则停止清理程序；
+// 如果之前没有运行清理程序则以间隔d启动一个
+func (c *CachePro[T]) SetCleanupInterval(d time.Duration) {
+	c.jmu.Lock()
+	defer c.jmu.Unlock()
+	if c.closed.Load() {
+		return
+	}
+	j := c.janitor
+	switch {
+	case j == nil && d > 0:
+		runJanitorPro[T](c.cachePro, d)
+	case j != nil && d <= 0:
+		c.janitor = nil
+		j.stop <- true
+	case j != nil:
+		j.reset <- d
+		j.Interval = d
+	}
+	c.cleanupInterval = max(d, 0)
+}
+
+// PauseJanitor 暂停清理程序，但保留已配置的清理间隔，以便之后通过ResumeJanitor恢复。
+// 暂停期间过期项目不会被自动删除（但仍不会被Get等方法返回）。如果没有运行清理程序则不执行任何操作
+func (c *CachePro[T]) PauseJanitor() {
+	c.jmu.Lock()
+	defer c.jmu.Unlock()
+	if j := c.janitor; j != nil {
+		c.janitor = nil
+		j.stop <- true
+	}
+}
+
+// ResumeJanitor 以最近一次配置的清理间隔重新启动被PauseJanitor暂停的清理程序。
+// 如果清理程序正在运行或从未配置过清理间隔则不执行任何操作。
+// 在暂停期间调用SetCleanupInterval会直接按新的间隔启动清理程序
+func (c *CachePro[T]) ResumeJanitor() {
+	c.jmu.Lock()
+	defer c.jmu.Unlock()
+	if c.janitor == nil && c.cleanupInterval > 0 {
+		runJanitorPro[T](c.cachePro, c.cleanupInterval)
+	}
+}
+
+// Close 停止清理程序和写回goroutine，然后像FlushWithCallback一样删除所有项目并对每个项目调用delFunc和onEvicted，
+// 以释放这些值持有的资源。写回模式下尚未刷新的脏键不会被刷新，需要时请先调用FlushNow。
+// 关闭之后CachePro不能再使用：任何写入都会panic，读取总是找不到项目（也不会调用WithLoader的加载函数，
+// GetOrLoad返回ErrClosed），清理程序也不能再启动。
+// 重复调用Close不执行任何操作
+func (c *CachePro[T]) Close() {
+	if !c.closed.CompareAndSwap(false, true) {
+		return
+	}
+	c.jmu.Lock()
+	if j := c.janitor; j != nil {
+		c.janitor = nil
+		j.stop <- true
+	}
+	c.cleanupInterval = 0
+	c.jmu.Unlock()
+	if c.writeBehind != nil {
+		close(c.writeBehind.stop)
+	}
+	c.FlushWithCallback()
+}
+
 func newCachePro[T any](de time.Duration, m map[string]ItemPro[T]) *cachePro[T] {
 	if de == 0 {
 		de = -1
@@ -377,13 +1838,22 @@ func newCachePro[T any](de time.Duration, m map[string]ItemPro[T]) *cachePro[T]
 	c := &cachePro[T]{
 		defaultExpiration: de,
 		items:             m,
+		workers:           newWorkerPool(defaultMaxWorkers),
+		clock:             realClock{},
+	}
+	// 与resetItems相同，传入的项目可能已带有版本号，避免之后分配重复的版本号
+	for _, v := range m {
+		c.version = max(c.version, v.Version)
 	}
 	return c
 }
 
-func newCacheProWithJanitor[T any](de time.Duration, ci time.Duration, m map[string]ItemPro[T], DelFunc func(T)) *CachePro[T] {
+func newCacheProWithJanitor[T any](de time.Duration, ci time.Duration, m map[string]ItemPro[T], DelFunc func(T), opts ...OptionPro[T]) *CachePro[T] {
 	c := newCachePro[T](de, m)
 	c.delFunc = DelFunc
+	for _, opt := range opts {
+		opt(c)
+	}
 	// This trick ensures that the janitor goroutine (which--granted it
 	// was enabled--is running DeleteExpired on c forever) does not keep
 	// the returned C object from being garbage collected. When it is
@@ -392,6 +1862,10 @@ func newCacheProWithJanitor[T any](de time.Duration, ci time.Duration, m map[str
 	C := &CachePro[T]{c}
 	if ci > 0 {
 		runJanitorPro[T](c, ci)
+	}
+	// The finalizer is set even without a janitor, since one may be started
+	// later by SetCleanupInterval.
+	if !c.noFinalizer {
 		runtime.SetFinalizer(C, stopJanitorPro[T])
 	}
 	return C
@@ -400,9 +1874,9 @@ func newCacheProWithJanitor[T any](de time.Duration, ci time.Duration, m map[str
 // 返回具有给定默认过期时间和清理间隔的新CachePro
 // 如果过期时间小于1（或NoExpiration），则CachePro中的项目永不过期（默认情况下），必须手动删除
 // 如果清理间隔小于1，则在调用c.DeleteExpired()之前不会从CachePro中删除过期项目
-func NewPro[T any](defaultExpiration, cleanupInterval time.Duration, DelFunc func(T)) *CachePro[T] {
+func NewPro[T any](defaultExpiration, cleanupInterval time.Duration, DelFunc func(T), opts ...OptionPro[T]) *CachePro[T] {
 	items := make(map[string]ItemPro[T])
-	return newCacheProWithJanitor[T](defaultExpiration, cleanupInterval, items, DelFunc)
+	return newCacheProWithJanitor[T](defaultExpiration, cleanupInterval, items, DelFunc, opts...)
 }
 
 // 返回具有给定默认过期时间和清理间隔的新CachePro
@@ -418,43 +1892,131 @@ func NewPro[T any](defaultExpiration, cleanupInterval time.Duration, DelFunc fun
 //
 // 关于序列化的注意事项：使用例如gob时，请确保在编码使用c.Items()检索的映射之前
 // 注册存储在CachePro中的各个类型，并在解码包含items映射的blob之前注册相同的类型
-func NewFromPro[T any](defaultExpiration, cleanupInterval time.Duration, items map[string]ItemPro[T]) *CachePro[T] {
-	return newCacheProWithJanitor[T](defaultExpiration, cleanupInterval, items, nil)
+func NewFromPro[T any](defaultExpiration, cleanupInterval time.Duration, items map[string]ItemPro[T], opts ...OptionPro[T]) *CachePro[T] {
+	return newCacheProWithJanitor[T](defaultExpiration, cleanupInterval, items, nil, opts...)
+}
+
+// 与NewFromPro相同，但同时指定delFunc，项目被删除或驱逐时（包括从items恢复的项目）会以其值调用delFunc
+func NewFromProWithDelFunc[T any](defaultExpiration, cleanupInterval time.Duration, items map[string]ItemPro[T], delFunc func(T), opts ...OptionPro[T]) *CachePro[T] {
+	return newCacheProWithJanitor[T](defaultExpiration, cleanupInterval, items, delFunc, opts...)
+}
+
+// 设置项目的最长存活时间。d大于0时，此后每次写入计算出的过期时间都不会晚于写入时间加d，
+// 包括以NoExpiration写入的项目（它们也会在d后过期）以及SetWithDeadline设置的绝对时间。
+// 已存在的项目不受影响。d小于等于0时取消限制
+func (c *CachePro[T]) SetMaxTTL(d time.Duration) {
+	c.mu.Lock()
+	c.maxTTL = max(d, 0)
+	c.mu.Unlock()
+}
+
+// 设置Compute在计算已存在的项目时是否将其过期时间重置为默认过期时间
+// 默认为false，即保持原有过期时间
+func (c *CachePro[T]) SetComputeRenewTTL(enabled bool) {
+	c.mu.Lock()
+	c.computeRenewTTL = enabled
+	c.mu.Unlock()
+}
+
+// 设置Compute在键不存在或已过期而存储defaultValue时是否使用默认过期时间
+// 默认为false，即defaultValue永不过期（除非设置了SetMaxTTL）
+func (c *CachePro[T]) SetComputeDefaultTTL(enabled bool) {
+	c.mu.Lock()
+	c.computeDefaultTTL = enabled
+	c.mu.Unlock()
+}
+
+// 返回Compute存储defaultValue时使用的过期时间，调用方必须持有写锁
+func (c *cachePro[T]) computeDefaultExpiration() int64 {
+	if c.computeDefaultTTL {
+		return c.expiration(c.defaultExpiration)
+	}
+	return c.expiration(NoExpiration)
+}
+
+// GetOrCompute 返回键k未过期的值；如果不存在或已过期，则调用compute并以过期时间d存储其结果
+// 检查和计算在同一个写锁内完成，因此并发调用者不会重复计算，但compute运行期间其他操作都会被阻塞，
+// 且compute不能回调CachePro的方法。耗时的计算请使用GetOrLoad
+// 与Compute（对已有的值进行变换）不同，compute只在值缺失时调用。compute返回错误时不存储任何内容
+func (c *CachePro[T]) GetOrCompute(k string, compute func() (T, error), d time.Duration) (T, error) {
+	c.mustBeOpen()
+	if v, found := c.Get(k); found {
+		return v, nil
+	}
+	c.mu.Lock()
+	if v, found := c.get(k); found {
+		c.mu.Unlock()
+		return v, nil
+	}
+	v, err := compute()
+	if err != nil {
+		c.mu.Unlock()
+		var zero T
+		return zero, err
+	}
+	c.set(k, v, d)
+	c.unlockAndEvict(k)
+	return v, nil
+}
+
+// GetOrSetFunc 返回键k未过期的值和true；如果不存在或已过期，则调用f，以过期时间d存储其结果并返回该结果和false
+// 与GetOrCompute一样，f在持有写锁时运行以保证检查和写入是原子的，因此f不能回调CachePro的方法
+func (c *CachePro[T]) GetOrSetFunc(k string, d time.Duration, f func() T) (T, bool) {
+	c.mustBeOpen()
+	if v, found := c.Get(k); found {
+		return v, true
+	}
+	c.mu.Lock()
+	if v, found := c.get(k); found {
+		c.mu.Unlock()
+		return v, true
+	}
+	v := f()
+	c.set(k, v, d)
+	c.unlockAndEvict(k)
+	return v, false
 }
 
 // 使用给定的计算函数对缓存中的项目进行计算操作
 // 计算函数接受两个T类型的参数并返回一个T类型的结果
+// 默认保持项目原有的过期时间，参见SetComputeRenewTTL；键不存在、已过期或是负缓存项目时存储defaultValue，
+// 默认永不过期，参见SetComputeDefaultTTL
 func (c *CachePro[T]) Compute(k string, computeFunc func(T, T) T, defaultValue T) (T, error) {
+	c.mustBeOpen()
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
 	item, found := c.items[k]
-	if !found {
-		// 如果键不存在，使用默认值
-		c.items[k] = ItemPro[T]{
+	if !found || item.Negative {
+		// 如果键不存在或是负缓存项目，使用默认值
+		c.overwrite(k, ItemPro[T]{
 			Object:     defaultValue,
-			Expiration: 0, // 永不过期
-		}
+			Expiration: c.computeDefaultExpiration(),
+		})
 		return defaultValue, nil
 	}
 
 	// 检查是否过期
-	if item.Expiration > 0 && time.Now().UnixNano() > item.Expiration {
+	if item.Expiration > 0 && c.clock.Now().UnixNano() > item.Expiration {
 		// 如果已过期，使用默认值
-		c.items[k] = ItemPro[T]{
+		c.overwrite(k, ItemPro[T]{
 			Object:     defaultValue,
-			Expiration: 0, // 永不过期
-		}
+			Expiration: c.computeDefaultExpiration(),
+		})
 		return defaultValue, nil
 	}
 
 	// 执行计算操作
 	currentValue := item.Object
 	newValue := computeFunc(currentValue, currentValue)
-	c.items[k] = ItemPro[T]{
-		Object:     newValue,
-		Expiration: item.Expiration, // 保持原有过期时间
+	e := item.Expiration // 保持原有过期时间
+	if c.computeRenewTTL {
+		e = c.expiration(c.defaultExpiration)
 	}
+	c.put(k, ItemPro[T]{
+		Object:     newValue,
+		Expiration: e,
+	})
 
 	return newValue, nil
 }
@@ -462,44 +2024,39 @@ func (c *CachePro[T]) Compute(k string, computeFunc func(T, T) T, defaultValue T
 // 使用给定的计算函数对缓存中的项目进行计算操作，并指定过期时间
 // 计算函数接受两个T类型的参数并返回一个T类型的结果
 func (c *CachePro[T]) ComputeWithExpiration(k string, computeFunc func(T, T) T, defaultValue T, d time.Duration) (T, error) {
+	c.mustBeOpen()
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
-	var e int64
-	if d == DefaultExpiration {
-		d = c.defaultExpiration
-	}
-	if d > 0 {
-		e = time.Now().Add(d).UnixNano()
-	}
+	e := c.expiration(d)
 
 	item, found := c.items[k]
-	if !found {
-		// 如果键不存在，使用默认值
-		c.items[k] = ItemPro[T]{
+	if !found || item.Negative {
+		// 如果键不存在或是负缓存项目，使用默认值
+		c.overwrite(k, ItemPro[T]{
 			Object:     defaultValue,
 			Expiration: e,
-		}
+		})
 		return defaultValue, nil
 	}
 
 	// 检查是否过期
-	if item.Expiration > 0 && time.Now().UnixNano() > item.Expiration {
+	if item.Expiration > 0 && c.clock.Now().UnixNano() > item.Expiration {
 		// 如果已过期，使用默认值
-		c.items[k] = ItemPro[T]{
+		c.overwrite(k, ItemPro[T]{
 			Object:     defaultValue,
 			Expiration: e,
-		}
+		})
 		return defaultValue, nil
 	}
 
 	// 执行计算操作
 	currentValue := item.Object
 	newValue := computeFunc(currentValue, currentValue)
-	c.items[k] = ItemPro[T]{
+	c.put(k, ItemPro[T]{
 		Object:     newValue,
 		Expiration: e, // 使用新的过期时间
-	}
+	})
 
 	return newValue, nil
 }
@@ -507,35 +2064,46 @@ func (c *CachePro[T]) ComputeWithExpiration(k string, computeFunc func(T, T) T,
 // 使用给定的计算函数对两个缓存键的值进行计算操作
 // 计算函数接受两个T类型的参数并返回一个T类型的结果
 func (c *CachePro[T]) ComputeTwoKeys(k1, k2 string, computeFunc func(T, T) T, resultKey string, d time.Duration) (T, error) {
+	return c.ComputeTwoKeysWithPolicy(k1, k2, computeFunc, resultKey, d, TTLExplicit)
+}
+
+// TTLPolicy 决定ComputeTwoKeysWithPolicy的结果使用的过期时间
+type TTLPolicy int
+
+const (
+	// 使用调用方传入的持续时间d
+	TTLExplicit TTLPolicy = iota
+	// 使用两个输入项目中较早的过期时间，即结果随最先过期的输入一起过期
+	TTLMinOfInputs
+	// 使用两个输入项目中较晚的过期时间
+	TTLMaxOfInputs
+)
+
+// 与ComputeTwoKeys相同，但结果的过期时间由policy决定。
+// 对于TTLMinOfInputs和TTLMaxOfInputs，参数d被忽略，永不过期的输入被视为过期时间无限远
+func (c *CachePro[T]) ComputeTwoKeysWithPolicy(k1, k2 string, computeFunc func(T, T) T, resultKey string, d time.Duration, policy TTLPolicy) (T, error) {
+	c.mustBeOpen()
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
-	var e int64
-	if d == DefaultExpiration {
-		d = c.defaultExpiration
-	}
-	if d > 0 {
-		e = time.Now().Add(d).UnixNano()
-	}
-
 	// 获取第一个键的值
 	item1, found1 := c.items[k1]
-	if !found1 {
+	if !found1 || item1.Negative {
 		var zero T
 		return zero, fmt.Errorf("key %s not found", k1)
 	}
-	if item1.Expiration > 0 && time.Now().UnixNano() > item1.Expiration {
+	if item1.Expiration > 0 && c.clock.Now().UnixNano() > item1.Expiration {
 		var zero T
 		return zero, fmt.Errorf("key %s has expired", k1)
 	}
 
 	// 获取第二个键的值
 	item2, found2 := c.items[k2]
-	if !found2 {
+	if !found2 || item2.Negative {
 		var zero T
 		return zero, fmt.Errorf("key %s not found", k2)
 	}
-	if item2.Expiration > 0 && time.Now().UnixNano() > item2.Expiration {
+	if item2.Expiration > 0 && c.clock.Now().UnixNano() > item2.Expiration {
 		var zero T
 		return zero, fmt.Errorf("key %s has expired", k2)
 	}
@@ -545,11 +2113,135 @@ func (c *CachePro[T]) ComputeTwoKeys(k1, k2 string, computeFunc func(T, T) T, re
 	value2 := item2.Object
 	result := computeFunc(value1, value2)
 
+	var e int64
+	switch policy {
+	case TTLMinOfInputs:
+		e = item1.Expiration
+		if e == 0 || (item2.Expiration > 0 && item2.Expiration < e) {
+			e = item2.Expiration
+		}
+	case TTLMaxOfInputs:
+		if item1.Expiration > 0 && item2.Expiration > 0 {
+			e = max(item1.Expiration, item2.Expiration)
+		}
+	default:
+		e = c.expiration(d)
+	}
+
 	// 存储结果
-	c.items[resultKey] = ItemPro[T]{
+	c.put(resultKey, ItemPro[T]{
 		Object:     result,
 		Expiration: e,
-	}
+	})
 
 	return result, nil
 }
+
+// 在同一个写锁内从initial开始按keys的顺序用reduce依次合并各个键的值，将结果以过期时间d存储到resultKey并返回
+// 任何一个键不存在、已过期或是负缓存项目时返回错误，不存储任何内容。keys为空时存储initial
+func (c *CachePro[T]) ComputeN(keys []string, reduce func(acc, v T) T, initial T, resultKey string, d time.Duration) (T, error) {
+	c.mustBeOpen()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.clock.Now().UnixNano()
+	acc := initial
+	for _, k := range keys {
+		item, found := c.items[k]
+		if !found || item.Negative {
+			var zero T
+			return zero, fmt.Errorf("key %s not found", k)
+		}
+		if item.Expiration > 0 && now > item.Expiration {
+			var zero T
+			return zero, fmt.Errorf("key %s has expired", k)
+		}
+		acc = reduce(acc, item.Object)
+	}
+
+	c.put(resultKey, ItemPro[T]{
+		Object:     acc,
+		Expiration: c.expiration(d),
+	})
+	return acc, nil
+}
+
+// 将int64类型的项目增加n，并返回增加后的值以及本次调用是否越过了阈值
+// （增加前小于threshold，增加后大于等于threshold）。读取、增加和比较在同一个写锁内完成
+// 如果键不存在、已过期或是负缓存项目，则从0开始计数并使用过期时间d创建该项目；否则保持原有过期时间
+// 如果项目的值不是int64，或者与Increment一样结果会溢出，则返回错误（值保持不变）
+func (c *CachePro[T]) IncrementThreshold(k string, n int64, threshold int64, d time.Duration) (int64, bool, error) {
+	c.mustBeOpen()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var cur int64
+	item, found := c.items[k]
+	if found && (item.Negative || (item.Expiration > 0 && c.clock.Now().UnixNano() > item.Expiration)) {
+		found = false
+	}
+	if found {
+		v, ok := any(item.Object).(int64)
+		if !ok {
+			return 0, false, fmt.Errorf("The value for %s is not an int64", k)
+		}
+		cur = v
+	}
+
+	nv, err := add(k, cur, n)
+	if err != nil {
+		return 0, false, err
+	}
+	x, ok := any(nv).(T)
+	if !ok {
+		return 0, false, fmt.Errorf("The value for %s is not an int64", k)
+	}
+	if found {
+		c.put(k, ItemPro[T]{
+			Object:     x,
+			Expiration: item.Expiration, // 保持原有过期时间
+		})
+	} else {
+		c.set(k, x, d)
+	}
+	return nv, cur < threshold && nv >= threshold, nil
+}
+
+// 在同一个写锁内使用f更新键k的值。f接收当前值以及该键是否存在且未过期（负缓存项目视为不存在），
+// 返回新值以及是否保留该键：返回false时删除该键（如果存在），否则存储新值。
+// 如果键已存在则保持原有过期时间，否则使用默认过期时间
+// 返回存储后的值以及该键是否被保留
+//
+// f在持有写锁时运行，因此不能回调CachePro的方法
+func (c *CachePro[T]) Update(k string, f func(old T, found bool) (T, bool)) (T, bool) {
+	c.mustBeOpen()
+	c.mu.Lock()
+	item, found := c.items[k]
+	if found && (item.Negative || (item.Expiration > 0 && c.clock.Now().UnixNano() > item.Expiration)) {
+		found = false
+	}
+	var old T
+	if found {
+		old = item.Object
+	}
+	nv, keep := f(old, found)
+	if !keep {
+		v, evicted := c.delete(k)
+		c.mu.Unlock()
+		if evicted {
+			c.notifyEvicted(k, v)
+		}
+		var zero T
+		return zero, false
+	}
+	if found {
+		c.put(k, ItemPro[T]{
+			Object:     nv,
+			Expiration: item.Expiration, // 保持原有过期时间
+		})
+	} else {
+		c.set(k, nv, DefaultExpiration)
+	}
+	c.mu.Unlock()
+	return nv, true
+}
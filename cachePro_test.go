@@ -1,6 +1,18 @@
 package cache
 
 import (
+	"bytes"
+	"encoding/gob"
+	"errors"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 )
@@ -46,30 +58,93 @@ func TestCacheProBasic(t *testing.T) {
 	}
 }
 
+// TestCacheProSetByValue 测试使用从值计算出的键存储
+func TestCacheProSetByValue(t *testing.T) {
+	tc := NewPro[string](DefaultExpiration, 0, nil)
+	keyFunc := func(s string) string {
+		return "len:" + strconv.Itoa(len(s))
+	}
+
+	k := tc.SetByValue("hello", keyFunc, DefaultExpiration)
+	if k != keyFunc("hello") {
+		t.Errorf("Expected key %s, got %s", keyFunc("hello"), k)
+	}
+	v, found := tc.Get(k)
+	if !found || v != "hello" {
+		t.Errorf("Expected 'hello' under %s, got '%v', %v", k, v, found)
+	}
+}
+
+// TestCacheProSetIfExpired 测试只刷新过期的条目
+func TestCacheProSetIfExpired(t *testing.T) {
+	tc := NewPro[string](DefaultExpiration, 0, nil)
+
+	// 存在且未过期
+	tc.Set("fresh", "old", DefaultExpiration)
+	if tc.SetIfExpired("fresh", "new", DefaultExpiration) {
+		t.Error("SetIfExpired wrote over a fresh value")
+	}
+	if v, _ := tc.Get("fresh"); v != "old" {
+		t.Errorf("Expected 'old', got '%v'", v)
+	}
+
+	// 存在但已过期
+	tc.Set("stale", "old", time.Millisecond)
+	<-time.After(5 * time.Millisecond)
+	if !tc.SetIfExpired("stale", "new", DefaultExpiration) {
+		t.Error("SetIfExpired did not write over an expired value")
+	}
+	if v, _ := tc.Get("stale"); v != "new" {
+		t.Errorf("Expected 'new', got '%v'", v)
+	}
+
+	// 不存在
+	if !tc.SetIfExpired("absent", "new", DefaultExpiration) {
+		t.Error("SetIfExpired did not write an absent key")
+	}
+	if v, _ := tc.Get("absent"); v != "new" {
+		t.Errorf("Expected 'new', got '%v'", v)
+	}
+}
+
 // TestCacheProExpiration 测试CachePro的过期功能
 func TestCacheProExpiration(t *testing.T) {
-	tc := NewPro[int](50*time.Millisecond, 1*time.Millisecond, nil)
+	clk := newFakeClock()
+	tc := NewPro[int](50*time.Millisecond, 0, nil, withClock[int](clk))
 
 	tc.Set("a", 1, DefaultExpiration)
 	tc.Set("b", 2, NoExpiration)
 	tc.Set("c", 3, 20*time.Millisecond)
 
-	<-time.After(25 * time.Millisecond)
+	clk.Advance(20 * time.Millisecond)
+	if _, found := tc.Get("c"); !found {
+		t.Error("c expired exactly at its deadline")
+	}
+
+	clk.Advance(5 * time.Millisecond)
 	_, found := tc.Get("c")
 	if found {
-		t.Error("Found c when it should have been automatically deleted")
+		t.Error("Found c when it should have expired")
+	}
+	tc.DeleteExpired()
+	if tc.ItemCount() != 2 {
+		t.Errorf("Expected DeleteExpired to remove c, got %d items", tc.ItemCount())
 	}
 
-	<-time.After(30 * time.Millisecond)
+	clk.Advance(30 * time.Millisecond)
 	_, found = tc.Get("a")
 	if found {
-		t.Error("Found a when it should have been automatically deleted")
+		t.Error("Found a when it should have expired")
 	}
 
 	_, found = tc.Get("b")
 	if !found {
 		t.Error("Did not find b even though it was set to never expire")
 	}
+	tc.DeleteExpired()
+	if tc.ItemCount() != 1 {
+		t.Errorf("Expected only b to remain, got %d items", tc.ItemCount())
+	}
 }
 
 // TestCacheProCompute 测试计算函数
@@ -110,9 +185,63 @@ func TestCacheProCompute(t *testing.T) {
 	}
 }
 
+// TestCacheProComputeRenewTTL 测试Compute是否重置过期时间
+func TestCacheProComputeRenewTTL(t *testing.T) {
+	tc := NewPro[int](time.Hour, 0, nil)
+	addFunc := func(a, b int) int {
+		return a + b
+	}
+
+	tc.Set("value", 1, time.Minute)
+	_, exp, _ := tc.GetWithExpiration("value")
+	tc.Compute("value", addFunc, 0)
+	_, newExp, _ := tc.GetWithExpiration("value")
+	if !newExp.Equal(exp) {
+		t.Errorf("Expected expiration %v to be preserved, got %v", exp, newExp)
+	}
+
+	tc.SetComputeRenewTTL(true)
+	tc.Compute("value", addFunc, 0)
+	_, newExp, _ = tc.GetWithExpiration("value")
+	if time.Until(newExp) <= 59*time.Minute {
+		t.Errorf("Expected expiration to be extended to the 1h default, got %v", time.Until(newExp))
+	}
+}
+
+// TestCacheProComputeDefaultTTL 测试启用后已过期或不存在的键以默认过期时间存储defaultValue
+func TestCacheProComputeDefaultTTL(t *testing.T) {
+	clk := newFakeClock()
+	tc := NewPro[int](time.Hour, 0, nil, withClock[int](clk))
+	addFunc := func(a, b int) int {
+		return a + b
+	}
+
+	tc.Set("value", 1, time.Minute)
+	clk.Advance(2 * time.Minute)
+	tc.Compute("value", addFunc, 10)
+	if _, exp, _ := tc.GetWithExpiration("value"); !exp.IsZero() {
+		t.Errorf("Expected the default value to be permanent by default, got %v", exp)
+	}
+
+	tc.SetComputeDefaultTTL(true)
+	tc.Set("value", 1, time.Minute)
+	clk.Advance(2 * time.Minute)
+	if v, _ := tc.Compute("value", addFunc, 10); v != 10 {
+		t.Errorf("Expected the default value 10, got %d", v)
+	}
+	if _, exp, _ := tc.GetWithExpiration("value"); !exp.Equal(clk.Now().Add(time.Hour)) {
+		t.Errorf("Expected the recomputed value to inherit the 1h default, got %v", exp)
+	}
+	tc.Compute("absent", addFunc, 10)
+	if _, exp, _ := tc.GetWithExpiration("absent"); !exp.Equal(clk.Now().Add(time.Hour)) {
+		t.Errorf("Expected a missing key to get the 1h default, got %v", exp)
+	}
+}
+
 // TestCacheProComputeWithExpiration 测试带过期时间的计算函数
 func TestCacheProComputeWithExpiration(t *testing.T) {
-	tc := NewPro[int](DefaultExpiration, 0, nil)
+	clk := newFakeClock()
+	tc := NewPro[int](DefaultExpiration, 0, nil, withClock[int](clk))
 
 	// 定义计算函数 - 乘法
 	multiplyFunc := func(a, b int) int {
@@ -139,7 +268,7 @@ func TestCacheProComputeWithExpiration(t *testing.T) {
 	}
 
 	// 验证过期时间
-	<-time.After(60 * time.Millisecond)
+	clk.Advance(60 * time.Millisecond)
 	_, found := tc.Get("num")
 	if found {
 		t.Error("num should have expired")
@@ -214,6 +343,71 @@ func TestCacheProDelete(t *testing.T) {
 	}
 }
 
+// TestCacheProRename 测试移动键并保持过期时间
+func TestCacheProRename(t *testing.T) {
+	tc := NewPro[string](DefaultExpiration, 0, nil)
+	tc.Set("tmp-1", "session", time.Hour)
+	tc.Set("perm-1", "old", DefaultExpiration)
+	_, exp, _ := tc.GetWithExpiration("tmp-1")
+
+	if !tc.Rename("tmp-1", "perm-1") {
+		t.Fatal("Rename returned false for an existing key")
+	}
+	if _, found := tc.Get("tmp-1"); found {
+		t.Error("tmp-1 was found after Rename")
+	}
+	v, newExp, found := tc.GetWithExpiration("perm-1")
+	if !found || v != "session" {
+		t.Errorf("Expected 'session' under perm-1, got '%v', %v", v, found)
+	}
+	if newExp.UnixNano() != exp.UnixNano() {
+		t.Errorf("Expected expiration %d to be preserved, got %d", exp.UnixNano(), newExp.UnixNano())
+	}
+
+	if tc.Rename("missing", "other") {
+		t.Error("Rename returned true for a missing key")
+	}
+	tc.Set("expired", "x", time.Millisecond)
+	<-time.After(5 * time.Millisecond)
+	if tc.Rename("expired", "other") {
+		t.Error("Rename returned true for an expired key")
+	}
+}
+
+// TestCacheProCompareAndDelete 测试只在值匹配时删除
+func TestCacheProCompareAndDelete(t *testing.T) {
+	tc := NewPro[string](DefaultExpiration, 0, nil)
+	tc.Set("lock", "owner-1", DefaultExpiration)
+	isOwner1 := func(v string) bool {
+		return v == "owner-1"
+	}
+
+	// 另一个写入者先替换了值
+	done := make(chan struct{})
+	go func() {
+		tc.Set("lock", "owner-2", DefaultExpiration)
+		close(done)
+	}()
+	<-done
+
+	if tc.CompareAndDelete("lock", isOwner1) {
+		t.Error("CompareAndDelete deleted a value replaced by another writer")
+	}
+	if v, _ := tc.Get("lock"); v != "owner-2" {
+		t.Errorf("Expected 'owner-2', got '%v'", v)
+	}
+
+	if !tc.CompareAndDelete("lock", func(v string) bool { return v == "owner-2" }) {
+		t.Error("CompareAndDelete refused a matching value")
+	}
+	if _, found := tc.Get("lock"); found {
+		t.Error("lock was found after CompareAndDelete")
+	}
+	if tc.CompareAndDelete("lock", isOwner1) {
+		t.Error("CompareAndDelete returned true for a missing key")
+	}
+}
+
 // TestCacheProFlush 测试清空功能
 func TestCacheProFlush(t *testing.T) {
 	tc := NewPro[int](DefaultExpiration, 0, nil)
@@ -249,6 +443,131 @@ func TestCacheProItemCount(t *testing.T) {
 	}
 }
 
+// TestCacheProItemCountLive 测试只统计未过期的项目
+func TestCacheProItemCountLive(t *testing.T) {
+	tc := NewPro[int](DefaultExpiration, 0, nil)
+
+	tc.Set("a", 1, DefaultExpiration)
+	tc.Set("b", 2, 10*time.Millisecond)
+	tc.Set("c", 3, 10*time.Millisecond)
+
+	<-time.After(20 * time.Millisecond)
+	if tc.ItemCount() != 3 {
+		t.Errorf("Expected ItemCount to include expired items, got %d", tc.ItemCount())
+	}
+	if tc.ItemCountLive() != 1 {
+		t.Errorf("Expected 1 live item, got %d", tc.ItemCountLive())
+	}
+}
+
+// TestCountExpiringWithin 测试只统计在时间窗口内过期的项目
+func TestCountExpiringWithin(t *testing.T) {
+	clk := newFakeClock()
+	tc := NewPro[int](DefaultExpiration, 0, nil, withClock[int](clk))
+	tc.Set("forever", 0, NoExpiration)
+	tc.Set("expired", 0, time.Second)
+	clk.Advance(2 * time.Second)
+	for i := 1; i <= 10; i++ {
+		tc.Set(strconv.Itoa(i), i, time.Duration(i)*time.Minute)
+	}
+
+	if n := tc.CountExpiringWithin(5 * time.Minute); n != 5 {
+		t.Errorf("Expected 5 items expiring within 5 minutes, got %d", n)
+	}
+	if n := tc.CountExpiringWithin(30 * time.Second); n != 0 {
+		t.Errorf("Expected no items expiring within 30 seconds, got %d", n)
+	}
+	if n := tc.CountExpiringWithin(time.Hour); n != 10 {
+		t.Errorf("Expected 10 items expiring within an hour, got %d", n)
+	}
+}
+
+// TestShrink 测试收缩后映射被重新分配，未过期的项目全部保留而已过期的项目被删除
+func TestShrink(t *testing.T) {
+	clk := newFakeClock()
+	tc := NewPro[int](DefaultExpiration, 0, nil, withClock[int](clk))
+	for i := 0; i < 1000; i++ {
+		tc.Set(strconv.Itoa(i), i, DefaultExpiration)
+	}
+	for i := 10; i < 1000; i++ {
+		tc.Delete(strconv.Itoa(i))
+	}
+	tc.Set("expired", -1, time.Second)
+	clk.Advance(2 * time.Second)
+
+	before := reflect.ValueOf(tc.items).Pointer()
+	tc.Shrink()
+	if reflect.ValueOf(tc.items).Pointer() == before {
+		t.Error("Expected the map to be reallocated")
+	}
+	if tc.ItemCount() != 10 {
+		t.Errorf("Expected 10 items after shrinking, got %d", tc.ItemCount())
+	}
+	for i := 0; i < 10; i++ {
+		if v, found := tc.Get(strconv.Itoa(i)); !found || v != i {
+			t.Errorf("Expected %d to survive the shrink, got %d, %v", i, v, found)
+		}
+	}
+}
+
+// 报告大量删除之后收缩释放的堆内存
+func BenchmarkShrink(b *testing.B) {
+	var reclaimed uint64
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		tc := NewPro[int](NoExpiration, 0, nil)
+		for j := 0; j < 100000; j++ {
+			tc.Set(strconv.Itoa(j), j, DefaultExpiration)
+		}
+		for j := 100; j < 100000; j++ {
+			tc.Delete(strconv.Itoa(j))
+		}
+		var before, after runtime.MemStats
+		runtime.GC()
+		runtime.ReadMemStats(&before)
+		b.StartTimer()
+		tc.Shrink()
+		b.StopTimer()
+		runtime.GC()
+		runtime.ReadMemStats(&after)
+		if before.HeapAlloc > after.HeapAlloc {
+			reclaimed += before.HeapAlloc - after.HeapAlloc
+		}
+		runtime.KeepAlive(tc)
+	}
+	b.ReportMetric(float64(reclaimed)/float64(b.N), "reclaimed-B/op")
+}
+
+// TestCacheProEstimatedEntryBytes 测试估算条目大小
+func TestCacheProEstimatedEntryBytes(t *testing.T) {
+	tc := NewPro[[]byte](DefaultExpiration, 0, nil)
+	sizeOf := func(b []byte) int64 {
+		return int64(len(b))
+	}
+	tc.Set("k", make([]byte, 100), DefaultExpiration)
+	tc.Set("longer-key", make([]byte, 100), DefaultExpiration)
+	tc.Set("big", make([]byte, 1000), DefaultExpiration)
+
+	small, found := tc.EstimatedEntryBytes("k", sizeOf)
+	if !found {
+		t.Fatal("k was not found")
+	}
+	if small <= 100 {
+		t.Errorf("Expected estimate to exceed the value size, got %d", small)
+	}
+	longer, _ := tc.EstimatedEntryBytes("longer-key", sizeOf)
+	if longer-small != int64(len("longer-key")-len("k")) {
+		t.Errorf("Expected key length to be accounted for, got %d vs %d", longer, small)
+	}
+	big, _ := tc.EstimatedEntryBytes("big", sizeOf)
+	if big-small != 900+int64(len("big")-len("k")) {
+		t.Errorf("Expected value size to be accounted for, got %d vs %d", big, small)
+	}
+	if _, found := tc.EstimatedEntryBytes("absent", sizeOf); found {
+		t.Error("absent was found")
+	}
+}
+
 // TestCacheProWithStruct 测试使用结构体
 func TestCacheProWithStruct(t *testing.T) {
 	type Person struct {
@@ -270,3 +589,1955 @@ func TestCacheProWithStruct(t *testing.T) {
 		t.Errorf("Expected person {Alice 30}, got %+v", result)
 	}
 }
+
+// TestCacheProJSONStruct 测试结构体类型的JSON保存和加载
+func TestCacheProJSONStruct(t *testing.T) {
+	type Person struct {
+		Name string
+		Age  int
+	}
+
+	tc := NewPro[Person](DefaultExpiration, 0, nil)
+	tc.Set("alice", Person{Name: "Alice", Age: 30}, DefaultExpiration)
+	tc.Set("bob", Person{Name: "Bob", Age: 25}, time.Hour)
+
+	buf := &bytes.Buffer{}
+	if err := tc.SaveJSON(buf); err != nil {
+		t.Fatalf("SaveJSON failed: %v", err)
+	}
+
+	oc := NewPro[Person](DefaultExpiration, 0, nil)
+	if err := oc.LoadJSON(buf); err != nil {
+		t.Fatalf("LoadJSON failed: %v", err)
+	}
+
+	alice, found := oc.Get("alice")
+	if !found || alice.Name != "Alice" || alice.Age != 30 {
+		t.Errorf("Expected {Alice 30}, got %+v", alice)
+	}
+	_, exp, found := oc.GetWithExpiration("bob")
+	if !found {
+		t.Fatal("bob was not found")
+	}
+	_, want, _ := tc.GetWithExpiration("bob")
+	if !exp.Equal(want) {
+		t.Errorf("Expected expiration %v, got %v", want, exp)
+	}
+}
+
+// TestCacheProJSONPrimitive 测试基本类型的JSON保存和加载，以及跳过已存在的键
+func TestCacheProJSONPrimitive(t *testing.T) {
+	tc := NewPro[int](DefaultExpiration, 0, nil)
+	tc.Set("a", 1, DefaultExpiration)
+	tc.Set("b", 2, DefaultExpiration)
+
+	buf := &bytes.Buffer{}
+	if err := tc.SaveJSON(buf); err != nil {
+		t.Fatalf("SaveJSON failed: %v", err)
+	}
+
+	oc := NewPro[int](DefaultExpiration, 0, nil)
+	oc.Set("a", 100, DefaultExpiration)
+	if err := oc.LoadJSON(buf); err != nil {
+		t.Fatalf("LoadJSON failed: %v", err)
+	}
+
+	if a, _ := oc.Get("a"); a != 100 {
+		t.Errorf("Expected existing a to be kept as 100, got %v", a)
+	}
+	if b, _ := oc.Get("b"); b != 2 {
+		t.Errorf("Expected b to be 2, got %v", b)
+	}
+}
+
+// TestCacheProMarshal 测试序列化为字节切片并完整恢复
+func TestCacheProMarshal(t *testing.T) {
+	tc := NewPro[string](DefaultExpiration, 0, nil)
+	tc.Set("a", "alpha", DefaultExpiration)
+	tc.Set("b", "beta", time.Hour)
+
+	data, err := tc.Marshal()
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+
+	oc := NewPro[string](DefaultExpiration, 0, nil)
+	oc.Set("c", "gamma", DefaultExpiration)
+	if err := oc.Unmarshal(data); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+
+	if a, _ := oc.Get("a"); a != "alpha" {
+		t.Errorf("Expected 'alpha', got '%v'", a)
+	}
+	if b, _ := oc.Get("b"); b != "beta" {
+		t.Errorf("Expected 'beta', got '%v'", b)
+	}
+	if _, found := oc.Get("c"); found {
+		t.Error("c should have been replaced by Unmarshal")
+	}
+}
+
+// TestCacheProUnmarshalDelFunc 测试Unmarshal将被替换的项目传给delFunc
+func TestCacheProUnmarshalDelFunc(t *testing.T) {
+	src := NewPro[int](DefaultExpiration, 0, nil)
+	src.Set("a", 10, DefaultExpiration)
+	data, err := src.Marshal()
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+
+	var deleted []int
+	tc := NewPro[int](DefaultExpiration, 0, func(v int) { deleted = append(deleted, v) })
+	tc.Set("a", 1, DefaultExpiration)
+	tc.Set("b", 2, DefaultExpiration)
+	if err := tc.Unmarshal(data); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	sort.Ints(deleted)
+	if len(deleted) != 2 || deleted[0] != 1 || deleted[1] != 2 {
+		t.Errorf("Expected delFunc to be called with 1 and 2, got %v", deleted)
+	}
+}
+
+// TestCacheProMarshalSkipsExpired 测试Marshal只序列化未过期的项目
+func TestCacheProMarshalSkipsExpired(t *testing.T) {
+	clk := newFakeClock()
+	tc := NewPro[string](DefaultExpiration, 0, nil, withClock[string](clk))
+	tc.Set("live", "alpha", time.Hour)
+	tc.Set("expired", "beta", time.Second)
+	clk.Advance(2 * time.Second)
+
+	data, err := tc.Marshal()
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	items := map[string]ItemPro[string]{}
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&items); err != nil {
+		t.Fatalf("Decode failed: %v", err)
+	}
+	if _, found := items["expired"]; found {
+		t.Error("Marshal serialized an expired item")
+	}
+	if items["live"].Object != "alpha" || len(items) != 1 {
+		t.Errorf("Expected only the live item, got %v", items)
+	}
+}
+
+// TestCacheProUnmarshalCorrupted 测试解码失败时缓存内容保持不变
+func TestCacheProUnmarshalCorrupted(t *testing.T) {
+	tc := NewPro[int](DefaultExpiration, 0, nil)
+	tc.Set("a", 1, DefaultExpiration)
+
+	if err := tc.Unmarshal([]byte("not a gob stream")); err == nil {
+		t.Error("Expected error for corrupted input")
+	}
+	if a, found := tc.Get("a"); !found || a != 1 {
+		t.Errorf("Expected cache to be unchanged, got %v, %v", a, found)
+	}
+	if tc.ItemCount() != 1 {
+		t.Errorf("Expected 1 item, got %d", tc.ItemCount())
+	}
+}
+
+// TestCacheProMissingKeys 测试比较两个缓存的索引
+func TestCacheProMissingKeys(t *testing.T) {
+	primary := NewPro[int](DefaultExpiration, 0, nil)
+	primary.Set("a", 1, NoExpiration)
+	primary.Set("b", 2, time.Hour)
+	primary.Set("c", 3, 2*time.Hour)
+	primary.Set("d", 4, NoExpiration)
+
+	replica := NewPro[int](DefaultExpiration, 0, nil)
+	replica.Set("a", 1, NoExpiration)
+	replica.Set("b", 2, 2*time.Hour)
+	replica.Set("c", 3, time.Hour)
+	replica.Set("e", 5, NoExpiration)
+
+	buf := &bytes.Buffer{}
+	if err := primary.SaveIndex(buf); err != nil {
+		t.Fatalf("SaveIndex failed: %v", err)
+	}
+	index, err := LoadIndex(buf)
+	if err != nil {
+		t.Fatalf("LoadIndex failed: %v", err)
+	}
+	if len(index) != 4 {
+		t.Errorf("Expected 4 keys in index, got %d", len(index))
+	}
+
+	missing := replica.MissingKeys(index)
+	if len(missing) != 2 || missing[0] != "c" || missing[1] != "d" {
+		t.Errorf("Expected [c d] to be missing, got %v", missing)
+	}
+}
+
+// TestCacheProValidator 测试清理程序驱逐校验失败的项目
+func TestCacheProValidator(t *testing.T) {
+	tc := NewPro[string](DefaultExpiration, 5*time.Millisecond, nil)
+	evicted := make(chan string, 10)
+	tc.OnEvicted(func(k string, v interface{}) {
+		evicted <- k
+	})
+	tc.Set("valid", "token-ok", DefaultExpiration)
+	tc.Set("revoked", "token-revoked", DefaultExpiration)
+	tc.SetValidator(func(k string, v string) bool {
+		return v != "token-revoked"
+	}, 2)
+
+	select {
+	case k := <-evicted:
+		if k != "revoked" {
+			t.Errorf("Expected revoked to be evicted, got %s", k)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("revoked was not evicted by the janitor")
+	}
+	if _, found := tc.Get("revoked"); found {
+		t.Error("revoked was found after validation")
+	}
+	if _, found := tc.Get("valid"); !found {
+		t.Error("valid was not found after validation")
+	}
+}
+
+// TestCacheProDeleteInvalid 测试手动运行校验
+func TestCacheProDeleteInvalid(t *testing.T) {
+	tc := NewPro[int](DefaultExpiration, 0, nil)
+	for i := 0; i < 10; i++ {
+		tc.Set(strconv.Itoa(i), i, DefaultExpiration)
+	}
+	tc.DeleteInvalid()
+	if tc.ItemCount() != 10 {
+		t.Errorf("Expected DeleteInvalid without a validator to be a no-op, got %d items", tc.ItemCount())
+	}
+
+	tc.SetValidator(func(k string, v int) bool {
+		return v%2 == 0
+	}, 1)
+	tc.DeleteInvalid()
+	if tc.ItemCount() != 5 {
+		t.Errorf("Expected 5 items after validation, got %d", tc.ItemCount())
+	}
+	if _, found := tc.Get("3"); found {
+		t.Error("3 should have been evicted")
+	}
+}
+
+// TestCacheProSetCleanupInterval 测试运行时修改清理间隔
+func TestCacheProSetCleanupInterval(t *testing.T) {
+	tc := NewPro[int](DefaultExpiration, time.Hour, nil)
+	tc.Set("a", 1, time.Millisecond)
+
+	<-time.After(10 * time.Millisecond)
+	if tc.ItemCount() != 1 {
+		t.Fatalf("Expected expired item to linger with a long interval, got %d items", tc.ItemCount())
+	}
+
+	tc.SetCleanupInterval(time.Millisecond)
+	<-time.After(20 * time.Millisecond)
+	if tc.ItemCount() != 0 {
+		t.Errorf("Expected expired item to be swept after shrinking the interval, got %d items", tc.ItemCount())
+	}
+
+	tc.SetCleanupInterval(0)
+	tc.Set("b", 2, time.Millisecond)
+	<-time.After(10 * time.Millisecond)
+	if tc.ItemCount() != 1 {
+		t.Errorf("Expected no sweeping after stopping the janitor, got %d items", tc.ItemCount())
+	}
+
+	// 之前未运行清理程序的缓存
+	nc := NewPro[int](DefaultExpiration, 0, nil)
+	nc.Set("a", 1, time.Millisecond)
+	nc.SetCleanupInterval(time.Millisecond)
+	<-time.After(20 * time.Millisecond)
+	if nc.ItemCount() != 0 {
+		t.Errorf("Expected janitor to be started, got %d items", nc.ItemCount())
+	}
+	nc.SetCleanupInterval(0)
+}
+
+// TestCacheProGetTTL 测试获取剩余过期时间
+func TestCacheProGetTTL(t *testing.T) {
+	clk := newFakeClock()
+	tc := NewPro[int](DefaultExpiration, 0, nil, withClock[int](clk))
+	tc.Set("expiring", 1, time.Hour)
+	tc.Set("forever", 2, NoExpiration)
+	tc.Set("expired", 3, time.Millisecond)
+
+	clk.Advance(5 * time.Millisecond)
+
+	ttl, found := tc.GetTTL("expiring")
+	if !found {
+		t.Error("expiring was not found")
+	}
+	if ttl != time.Hour-5*time.Millisecond {
+		t.Errorf("Expected TTL of 1h minus 5ms, got %v", ttl)
+	}
+
+	ttl, found = tc.GetTTL("forever")
+	if !found || ttl != NoExpiration {
+		t.Errorf("Expected NoExpiration for forever, got %v, %v", ttl, found)
+	}
+
+	ttl, found = tc.GetTTL("expired")
+	if found || ttl != 0 {
+		t.Errorf("Expected (0, false) for expired, got %v, %v", ttl, found)
+	}
+
+	if _, found = tc.GetTTL("absent"); found {
+		t.Error("absent was found")
+	}
+}
+
+// TestCacheProIncrementThreshold 测试越过阈值只报告一次
+func TestCacheProIncrementThreshold(t *testing.T) {
+	tc := NewPro[int64](DefaultExpiration, 0, nil)
+
+	crossings := 0
+	for i := 0; i < 10; i++ {
+		v, crossed, err := tc.IncrementThreshold("errors", 1, 5, DefaultExpiration)
+		if err != nil {
+			t.Fatalf("IncrementThreshold failed: %v", err)
+		}
+		if v != int64(i+1) {
+			t.Errorf("Expected %d, got %d", i+1, v)
+		}
+		if crossed {
+			crossings++
+			if v != 5 {
+				t.Errorf("Expected threshold to be crossed at 5, got %d", v)
+			}
+		}
+	}
+	if crossings != 1 {
+		t.Errorf("Expected threshold to be crossed exactly once, got %d", crossings)
+	}
+
+	sc := NewPro[string](DefaultExpiration, 0, nil)
+	if _, _, err := sc.IncrementThreshold("k", 1, 5, DefaultExpiration); err == nil {
+		t.Error("Expected error for a non-int64 cache")
+	}
+
+	tc.Set("max", math.MaxInt64, DefaultExpiration)
+	if _, crossed, err := tc.IncrementThreshold("max", 1, 5, DefaultExpiration); err == nil || crossed {
+		t.Errorf("Expected an overflow error, got crossed=%v, %v", crossed, err)
+	}
+	if v, _ := tc.Get("max"); v != math.MaxInt64 {
+		t.Errorf("Expected the value to be unchanged, got %d", v)
+	}
+}
+
+// TestCacheProUpdate 测试Update的创建、更新和删除
+func TestCacheProUpdate(t *testing.T) {
+	tc := NewPro[[]string](DefaultExpiration, 0, nil)
+	appendFunc := func(s string) func([]string, bool) ([]string, bool) {
+		return func(old []string, found bool) ([]string, bool) {
+			return append(old, s), true
+		}
+	}
+
+	// 创建
+	v, kept := tc.Update("list", appendFunc("a"))
+	if !kept || len(v) != 1 || v[0] != "a" {
+		t.Errorf("Expected [a], got %v, %v", v, kept)
+	}
+
+	// 更新并保持过期时间
+	tc.Set("list", []string{"a"}, time.Hour)
+	_, exp, _ := tc.GetWithExpiration("list")
+	v, kept = tc.Update("list", appendFunc("b"))
+	if !kept || len(v) != 2 || v[1] != "b" {
+		t.Errorf("Expected [a b], got %v, %v", v, kept)
+	}
+	_, newExp, _ := tc.GetWithExpiration("list")
+	if !newExp.Equal(exp) {
+		t.Errorf("Expected expiration %v to be preserved, got %v", exp, newExp)
+	}
+
+	// 返回false时删除
+	var sawFound bool
+	_, kept = tc.Update("list", func(old []string, found bool) ([]string, bool) {
+		sawFound = found
+		return nil, false
+	})
+	if kept || !sawFound {
+		t.Errorf("Expected delete of an existing key, got kept=%v found=%v", kept, sawFound)
+	}
+	if _, found := tc.Get("list"); found {
+		t.Error("list was found after Update returned false")
+	}
+}
+
+// TestCacheProLoadJSONDuplicateKeys 测试数据流中重复键的处理策略
+func TestCacheProLoadJSONDuplicateKeys(t *testing.T) {
+	stream := `{"a":{"Object":1,"Expiration":0},"b":{"Object":3,"Expiration":0},"a":{"Object":2,"Expiration":0}}`
+
+	tc := NewPro[int](DefaultExpiration, 0, nil)
+	if err := tc.LoadJSON(bytes.NewBufferString(stream)); err != nil {
+		t.Fatalf("LoadJSON failed: %v", err)
+	}
+	if a, _ := tc.Get("a"); a != 2 {
+		t.Errorf("Expected last-wins to keep 2, got %v", a)
+	}
+
+	tc = NewPro[int](DefaultExpiration, 0, nil)
+	tc.SetDuplicateKeyPolicy(DuplicateFirstWins)
+	if err := tc.LoadJSON(bytes.NewBufferString(stream)); err != nil {
+		t.Fatalf("LoadJSON failed: %v", err)
+	}
+	if a, _ := tc.Get("a"); a != 1 {
+		t.Errorf("Expected first-wins to keep 1, got %v", a)
+	}
+
+	tc = NewPro[int](DefaultExpiration, 0, nil)
+	tc.SetDuplicateKeyPolicy(DuplicateError)
+	if err := tc.LoadJSON(bytes.NewBufferString(stream)); err == nil {
+		t.Error("Expected error for a duplicated key")
+	}
+	if tc.ItemCount() != 0 {
+		t.Errorf("Expected nothing to be imported on error, got %d items", tc.ItemCount())
+	}
+}
+
+// TestCacheProAsyncEvictionCallbacks 测试异步执行onEvicted
+func TestCacheProAsyncEvictionCallbacks(t *testing.T) {
+	tc := NewPro[int](DefaultExpiration, 0, nil)
+	tc.SetMaxWorkers(2)
+	tc.AsyncEvictionCallbacks(true)
+
+	var mu sync.Mutex
+	seen := map[string][]int{}
+	tc.OnEvicted(func(k string, v interface{}) {
+		time.Sleep(time.Millisecond)
+		mu.Lock()
+		seen[k] = append(seen[k], v.(int))
+		mu.Unlock()
+	})
+
+	for i := 0; i < 20; i++ {
+		k := strconv.Itoa(i % 5)
+		tc.Set(k, i, DefaultExpiration)
+		tc.Delete(k)
+	}
+	tc.Flush()
+
+	mu.Lock()
+	defer mu.Unlock()
+	total := 0
+	for k, vs := range seen {
+		total += len(vs)
+		for i := 1; i < len(vs); i++ {
+			if vs[i] < vs[i-1] {
+				t.Errorf("Expected callbacks for %s in eviction order, got %v", k, vs)
+			}
+		}
+	}
+	if total != 20 {
+		t.Errorf("Expected 20 callbacks after Flush, got %d", total)
+	}
+}
+
+// TestHas 测试Has对存在、过期和不存在的键的判断
+func TestHas(t *testing.T) {
+	tc := NewPro[int](DefaultExpiration, 0, nil)
+	tc.Set("present", 1, NoExpiration)
+	tc.Set("expired", 2, time.Millisecond)
+	<-time.After(5 * time.Millisecond)
+
+	if !tc.Has("present") {
+		t.Error("Expected Has(present) to be true")
+	}
+	if tc.Has("expired") {
+		t.Error("Expected Has(expired) to be false")
+	}
+	if tc.Has("absent") {
+		t.Error("Expected Has(absent) to be false")
+	}
+}
+
+type largeValue struct {
+	data [4096]byte
+}
+
+func BenchmarkCacheProHasLarge(b *testing.B) {
+	b.StopTimer()
+	tc := NewPro[largeValue](NoExpiration, 0, nil)
+	tc.Set("foo", largeValue{}, DefaultExpiration)
+	b.StartTimer()
+	for i := 0; i < b.N; i++ {
+		tc.Has("foo")
+	}
+}
+
+func BenchmarkCacheProGetLarge(b *testing.B) {
+	b.StopTimer()
+	tc := NewPro[largeValue](NoExpiration, 0, nil)
+	tc.Set("foo", largeValue{}, DefaultExpiration)
+	b.StartTimer()
+	for i := 0; i < b.N; i++ {
+		tc.Get("foo")
+	}
+}
+
+// TestGetOrError 测试键不存在或已过期时返回ErrNotFound
+func TestGetOrError(t *testing.T) {
+	clk := newFakeClock()
+	tc := NewPro[int](DefaultExpiration, 0, nil, withClock[int](clk))
+	tc.Set("a", 1, DefaultExpiration)
+	tc.Set("expired", 2, time.Second)
+	clk.Advance(2 * time.Second)
+
+	if v, err := tc.GetOrError("a"); err != nil || v != 1 {
+		t.Errorf("Expected 1, nil, got %d, %v", v, err)
+	}
+	for _, k := range []string{"absent", "expired"} {
+		v, err := tc.GetOrError(k)
+		if !errors.Is(err, ErrNotFound) {
+			t.Errorf("Expected ErrNotFound for %s, got %v", k, err)
+		}
+		if v != 0 {
+			t.Errorf("Expected the zero value for %s, got %d", k, v)
+		}
+		if err != nil && !strings.Contains(err.Error(), k) {
+			t.Errorf("Expected the error to name the key %s, got %v", k, err)
+		}
+	}
+}
+
+// TestAddReplaceErrors 测试Add和Replace返回可以用errors.Is判断的错误，且错误信息包含键名
+func TestAddReplaceErrors(t *testing.T) {
+	tc := NewPro[int](DefaultExpiration, 0, nil)
+	tc.Set("a", 1, DefaultExpiration)
+
+	err := tc.Add("a", 2, DefaultExpiration)
+	if !errors.Is(err, ErrKeyExists) {
+		t.Errorf("Expected ErrKeyExists, got %v", err)
+	}
+	if err != nil && !strings.Contains(err.Error(), "a") {
+		t.Errorf("Expected the error to name the key, got %v", err)
+	}
+
+	for name, err := range map[string]error{
+		"Replace":        tc.Replace("missing", 1, DefaultExpiration),
+		"ReplaceKeepTTL": tc.ReplaceKeepTTL("missing", 1),
+	} {
+		if !errors.Is(err, ErrKeyNotFound) || !errors.Is(err, ErrNotFound) {
+			t.Errorf("%s: expected ErrKeyNotFound, got %v", name, err)
+		}
+		if errors.Is(err, ErrKeyExists) {
+			t.Errorf("%s: did not expect ErrKeyExists", name)
+		}
+		if err != nil && !strings.Contains(err.Error(), "missing") {
+			t.Errorf("%s: expected the error to name the key, got %v", name, err)
+		}
+	}
+}
+
+// TestNegativeEntryIsMissing 测试GetTTL、Update和Rename与Get一样把负缓存项目视为不存在
+func TestNegativeEntryIsMissing(t *testing.T) {
+	tc := NewPro[int](DefaultExpiration, 0, nil)
+	tc.SetNegative("neg", time.Minute)
+
+	if ttl, found := tc.GetTTL("neg"); found || ttl != 0 {
+		t.Errorf("Expected GetTTL to return (0, false), got (%v, %v)", ttl, found)
+	}
+	if tc.Rename("neg", "renamed") {
+		t.Error("Rename moved a negative entry")
+	}
+	if _, _, found := tc.GetEntry("renamed"); found {
+		t.Error("Rename created the new key")
+	}
+	v, kept := tc.Update("neg", func(old int, found bool) (int, bool) {
+		if found {
+			t.Error("Update saw a negative entry as found")
+		}
+		return old + 1, true
+	})
+	if !kept || v != 1 {
+		t.Errorf("Expected Update to store 1, got %d, %v", v, kept)
+	}
+	if got, found := tc.Get("neg"); !found || got != 1 {
+		t.Errorf("Expected the negative entry to be replaced with 1, got %d, %v", got, found)
+	}
+}
+
+// TestKeysSkipsNegative 测试Keys与Range一样不返回负缓存项目和已过期的项目
+func TestKeysSkipsNegative(t *testing.T) {
+	clk := newFakeClock()
+	tc := NewPro[int](DefaultExpiration, 0, nil, withClock[int](clk))
+	tc.Set("a", 1, DefaultExpiration)
+	tc.SetNegative("neg", time.Minute)
+	tc.Set("expired", 2, time.Second)
+	clk.Advance(2 * time.Second)
+
+	if keys := tc.Keys(); len(keys) != 1 || keys[0] != "a" {
+		t.Errorf("Expected only a, got %v", keys)
+	}
+}
+
+// TestNegativeEntryComputePaths 测试Compute系列、CompareAndDelete和IncrementThreshold把负缓存项目视为不存在
+func TestNegativeEntryComputePaths(t *testing.T) {
+	tc := NewPro[int](DefaultExpiration, 0, nil)
+	add := func(a, b int) int { return a + b }
+
+	tc.SetNegative("neg", time.Minute)
+	if v, err := tc.Compute("neg", func(a, b int) int {
+		t.Error("Compute ran computeFunc on a negative entry")
+		return a
+	}, 7); err != nil || v != 7 {
+		t.Errorf("Expected Compute to store the default 7, got %d, %v", v, err)
+	}
+	if v, _ := tc.Get("neg"); v != 7 {
+		t.Errorf("Expected 7 after Compute, got %d", v)
+	}
+
+	tc.SetNegative("neg", time.Minute)
+	if v, err := tc.ComputeWithExpiration("neg", func(a, b int) int {
+		t.Error("ComputeWithExpiration ran computeFunc on a negative entry")
+		return a
+	}, 8, time.Minute); err != nil || v != 8 {
+		t.Errorf("Expected ComputeWithExpiration to store the default 8, got %d, %v", v, err)
+	}
+
+	tc.Set("five", 5, DefaultExpiration)
+	tc.SetNegative("neg", time.Minute)
+	if v, err := tc.ComputeTwoKeys("five", "neg", add, "sum", DefaultExpiration); err == nil {
+		t.Errorf("Expected an error for a negative input, got %d", v)
+	}
+	if _, err := tc.ComputeTwoKeys("neg", "five", add, "sum", DefaultExpiration); err == nil {
+		t.Error("Expected an error for a negative first input")
+	}
+	if _, found := tc.Get("sum"); found {
+		t.Error("ComputeTwoKeys stored a result for a negative input")
+	}
+
+	if tc.CompareAndDelete("neg", func(int) bool { return true }) {
+		t.Error("CompareAndDelete deleted a negative entry")
+	}
+	if _, state, found := tc.GetEntry("neg"); !found || state != EntryNegative {
+		t.Error("The negative entry should still be cached")
+	}
+
+	ic := NewPro[int64](DefaultExpiration, 0, nil)
+	ic.SetNegative("n", time.Minute)
+	if v, _, err := ic.IncrementThreshold("n", 3, 10, DefaultExpiration); err != nil || v != 3 {
+		t.Errorf("Expected IncrementThreshold to start from 0, got %d, %v", v, err)
+	}
+	if v, found := ic.Get("n"); !found || v != 3 {
+		t.Errorf("Expected 3 to be stored, got %d, %v", v, found)
+	}
+}
+
+// TestCloneKeepsVersionsIncreasing 测试从已有项目创建的CachePro分配的版本号大于所有已有的版本号
+func TestCloneKeepsVersionsIncreasing(t *testing.T) {
+	tc := NewPro[int](DefaultExpiration, 0, nil)
+	for i := 0; i < 5; i++ {
+		tc.Set("a", i, DefaultExpiration)
+	}
+	_, old, _ := tc.GetVersioned("a")
+
+	for name, cc := range map[string]*CachePro[int]{
+		"Clone":      tc.Clone(),
+		"NewFromPro": NewFromPro[int](DefaultExpiration, 0, tc.Items()),
+	} {
+		cc.Set("b", 1, DefaultExpiration)
+		if _, v, _ := cc.GetVersioned("b"); v <= old {
+			t.Errorf("%s: expected a version greater than %d, got %d", name, old, v)
+		}
+		if cc.SetIfVersion("a", 100, old-1, DefaultExpiration) {
+			t.Errorf("%s: SetIfVersion accepted a stale version", name)
+		}
+	}
+}
+
+func BenchmarkCacheProGetNotExpiring(b *testing.B) {
+	b.StopTimer()
+	tc := NewPro[string](NoExpiration, 0, nil)
+	tc.Set("foo", "bar", DefaultExpiration)
+	b.StartTimer()
+	for i := 0; i < b.N; i++ {
+		tc.Get("foo")
+	}
+}
+
+func BenchmarkCacheProGetNoExpiryCheck(b *testing.B) {
+	b.StopTimer()
+	tc := NewPro[string](NoExpiration, 0, nil)
+	tc.Set("foo", "bar", DefaultExpiration)
+	b.StartTimer()
+	for i := 0; i < b.N; i++ {
+		tc.GetNoExpiryCheck("foo")
+	}
+}
+
+// TestGetNoExpiryCheck 测试在永不过期的CachePro中与Get的结果一致
+func TestGetNoExpiryCheck(t *testing.T) {
+	tc := NewPro[int](NoExpiration, 0, nil)
+	for i := 0; i < 10; i++ {
+		tc.Set(strconv.Itoa(i), i, DefaultExpiration)
+	}
+	tc.SetNegative("neg", DefaultExpiration)
+	for _, k := range []string{"0", "5", "9", "absent", "neg"} {
+		v1, ok1 := tc.Get(k)
+		v2, ok2 := tc.GetNoExpiryCheck(k)
+		if v1 != v2 || ok1 != ok2 {
+			t.Errorf("Mismatch for %s: Get returned %d, %v; GetNoExpiryCheck returned %d, %v", k, v1, ok1, v2, ok2)
+		}
+	}
+}
+
+// TestSetNegative 测试负缓存项目被Get视为不存在，并在过期后恢复为普通的未命中
+func TestSetNegative(t *testing.T) {
+	tc := NewPro[int](DefaultExpiration, 0, nil)
+	tc.SetNegative("a", 20*time.Millisecond)
+
+	if _, found := tc.Get("a"); found {
+		t.Error("Get returned a negative entry as found")
+	}
+	if tc.Has("a") {
+		t.Error("Has returned true for a negative entry")
+	}
+	v, state, found := tc.GetEntry("a")
+	if !found || state != EntryNegative || v != 0 {
+		t.Errorf("Expected negative entry, got %d, %v, %v", v, state, found)
+	}
+
+	<-time.After(30 * time.Millisecond)
+	if _, state, found := tc.GetEntry("a"); found || state != EntryPresent {
+		t.Errorf("Expected expired negative entry to be a plain miss, got %v, %v", state, found)
+	}
+
+	tc.SetNegative("b", DefaultExpiration)
+	tc.Set("b", 0, DefaultExpiration)
+	v, state, found = tc.GetEntry("b")
+	if !found || state != EntryPresent || v != 0 {
+		t.Errorf("Expected present zero value, got %d, %v, %v", v, state, found)
+	}
+	if _, found := tc.Get("b"); !found {
+		t.Error("Set did not replace the negative entry")
+	}
+	if err := tc.Add("c", 1, DefaultExpiration); err != nil {
+		t.Error("Couldn't add c:", err)
+	}
+	tc.SetNegative("c", DefaultExpiration)
+	if err := tc.Add("c", 2, DefaultExpiration); err != nil {
+		t.Error("Add failed over a negative entry:", err)
+	}
+}
+
+// TestDeleteManyAndMatching 测试批量删除和按前缀删除，并检查onEvicted对每个项目都被调用
+func TestDeleteManyAndMatching(t *testing.T) {
+	tc := NewPro[int](DefaultExpiration, 0, nil)
+	var mu sync.Mutex
+	evicted := map[string]int{}
+	tc.OnEvicted(func(k string, v interface{}) {
+		mu.Lock()
+		evicted[k] = v.(int)
+		mu.Unlock()
+	})
+	for i := 0; i < 3; i++ {
+		tc.Set("tenant1:"+strconv.Itoa(i), i, DefaultExpiration)
+		tc.Set("tenant2:"+strconv.Itoa(i), 10+i, DefaultExpiration)
+	}
+	tc.Set("other", 100, DefaultExpiration)
+
+	n := tc.DeleteMatching(func(k string) bool {
+		return strings.HasPrefix(k, "tenant1:")
+	})
+	if n != 3 {
+		t.Errorf("Expected DeleteMatching to remove 3 items, got %d", n)
+	}
+	tc.DeleteMany([]string{"tenant2:0", "tenant2:1", "missing"})
+
+	if tc.ItemCount() != 2 {
+		t.Errorf("Expected 2 items left, got %d", tc.ItemCount())
+	}
+	if _, found := tc.Get("tenant2:2"); !found {
+		t.Error("tenant2:2 was deleted")
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(evicted) != 5 {
+		t.Errorf("Expected 5 eviction callbacks, got %v", evicted)
+	}
+	if evicted["tenant1:2"] != 2 || evicted["tenant2:1"] != 11 {
+		t.Errorf("Unexpected eviction values: %v", evicted)
+	}
+}
+
+// TestComputeN 测试对多个键求和，以及中间的键不存在时返回错误且不存储结果
+func TestComputeN(t *testing.T) {
+	tc := NewPro[int](DefaultExpiration, 0, nil)
+	keys := []string{"a", "b", "c", "d", "e"}
+	for i, k := range keys {
+		tc.Set(k, i+1, DefaultExpiration)
+	}
+	add := func(acc, v int) int { return acc + v }
+
+	sum, err := tc.ComputeN(keys, add, 0, "sum", DefaultExpiration)
+	if err != nil || sum != 15 {
+		t.Errorf("Expected sum 15, got %d, %v", sum, err)
+	}
+	if v, _ := tc.Get("sum"); v != 15 {
+		t.Errorf("Expected the stored sum to be 15, got %d", v)
+	}
+
+	tc.Delete("c")
+	if _, err := tc.ComputeN(keys, add, 0, "sum2", DefaultExpiration); err == nil {
+		t.Error("Expected an error when a middle key is absent")
+	}
+	if _, found := tc.Get("sum2"); found {
+		t.Error("Result was stored despite the error")
+	}
+
+	// 负缓存项目视为不存在，而不是按零值参与计算
+	tc.SetNegative("c", DefaultExpiration)
+	if _, err := tc.ComputeN(keys, add, 0, "sum3", DefaultExpiration); err == nil {
+		t.Error("Expected an error when a key is a negative entry")
+	}
+}
+
+// TestComputeTwoKeysWithPolicy 测试结果继承输入项目的过期时间
+func TestComputeTwoKeysWithPolicy(t *testing.T) {
+	tc := NewPro[int](DefaultExpiration, 0, nil)
+	tc.Set("short", 1, time.Minute)
+	tc.Set("long", 2, time.Hour)
+	tc.Set("forever", 3, NoExpiration)
+	add := func(a, b int) int { return a + b }
+
+	expiry := func(k string) int64 {
+		tc.mu.RLock()
+		defer tc.mu.RUnlock()
+		return tc.items[k].Expiration
+	}
+
+	if _, err := tc.ComputeTwoKeysWithPolicy("short", "long", add, "min", time.Second, TTLMinOfInputs); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := expiry("min"), expiry("short"); got != want {
+		t.Errorf("Expected min policy expiration %d, got %d", want, got)
+	}
+
+	if _, err := tc.ComputeTwoKeysWithPolicy("forever", "long", add, "min2", time.Second, TTLMinOfInputs); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := expiry("min2"), expiry("long"); got != want {
+		t.Errorf("Expected min policy to ignore non-expiring input, got %d, want %d", got, want)
+	}
+
+	if _, err := tc.ComputeTwoKeysWithPolicy("short", "long", add, "max", time.Second, TTLMaxOfInputs); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := expiry("max"), expiry("long"); got != want {
+		t.Errorf("Expected max policy expiration %d, got %d", want, got)
+	}
+
+	if _, err := tc.ComputeTwoKeysWithPolicy("short", "forever", add, "max2", time.Second, TTLMaxOfInputs); err != nil {
+		t.Fatal(err)
+	}
+	if got := expiry("max2"); got != 0 {
+		t.Errorf("Expected max policy with a non-expiring input to never expire, got %d", got)
+	}
+}
+
+// TestPauseResumeJanitor 测试暂停期间过期项目不会被清理，恢复后按原间隔清理
+func TestPauseResumeJanitor(t *testing.T) {
+	tc := NewPro[int](DefaultExpiration, time.Millisecond, nil)
+	tc.PauseJanitor()
+	tc.PauseJanitor()
+	tc.Set("a", 1, time.Millisecond)
+
+	<-time.After(20 * time.Millisecond)
+	if tc.ItemCount() != 1 {
+		t.Fatalf("Expected expired item to linger while paused, got %d items", tc.ItemCount())
+	}
+
+	tc.ResumeJanitor()
+	tc.ResumeJanitor()
+	<-time.After(20 * time.Millisecond)
+	if tc.ItemCount() != 0 {
+		t.Errorf("Expected expired item to be swept after resume, got %d items", tc.ItemCount())
+	}
+	tc.SetCleanupInterval(0)
+
+	// 从未配置清理程序的缓存
+	nc := NewPro[int](DefaultExpiration, 0, nil)
+	nc.PauseJanitor()
+	nc.ResumeJanitor()
+	nc.Set("a", 1, time.Millisecond)
+	<-time.After(10 * time.Millisecond)
+	if nc.ItemCount() != 1 {
+		t.Errorf("Expected ResumeJanitor to be a no-op without an interval, got %d items", nc.ItemCount())
+	}
+}
+
+// TestSwap 测试Swap返回旧值并存储新值
+func TestSwap(t *testing.T) {
+	tc := NewPro[string](DefaultExpiration, 0, nil)
+	old, hadOld := tc.Swap("a", "first", DefaultExpiration)
+	if hadOld || old != "" {
+		t.Errorf("Expected no old value, got %q, %v", old, hadOld)
+	}
+	old, hadOld = tc.Swap("a", "second", DefaultExpiration)
+	if !hadOld || old != "first" {
+		t.Errorf("Expected old value first, got %q, %v", old, hadOld)
+	}
+	if v, _ := tc.Get("a"); v != "second" {
+		t.Errorf("Expected stored value second, got %q", v)
+	}
+
+	tc.Set("b", "stale", time.Millisecond)
+	<-time.After(5 * time.Millisecond)
+	old, hadOld = tc.Swap("b", "fresh", DefaultExpiration)
+	if hadOld || old != "" {
+		t.Errorf("Expected expired value to count as missing, got %q, %v", old, hadOld)
+	}
+	if v, _ := tc.Get("b"); v != "fresh" {
+		t.Errorf("Expected stored value fresh, got %q", v)
+	}
+}
+
+// TestGetAs 测试从interface{}类型的CachePro中读取并断言类型
+func TestGetAs(t *testing.T) {
+	tc := NewPro[interface{}](DefaultExpiration, 0, nil)
+	tc.Set("int", 42, DefaultExpiration)
+	tc.Set("string", "hello", DefaultExpiration)
+
+	if v, ok := GetAs[int](tc, "int"); !ok || v != 42 {
+		t.Errorf("Expected 42, got %d, %v", v, ok)
+	}
+	if v, ok := GetAs[string](tc, "string"); !ok || v != "hello" {
+		t.Errorf("Expected hello, got %q, %v", v, ok)
+	}
+	if v, ok := GetAs[float64](tc, "int"); ok || v != 0 {
+		t.Errorf("Expected a failed assertion, got %v, %v", v, ok)
+	}
+	if v, ok := GetAs[error](tc, "int"); ok || v != nil {
+		t.Errorf("Expected a failed interface assertion, got %v, %v", v, ok)
+	}
+	if _, ok := GetAs[int](tc, "missing"); ok {
+		t.Error("GetAs found a missing key")
+	}
+}
+
+// TestCompareAndSwap 测试CompareAndSwap的成功替换、值不匹配和键不存在的情况
+func TestCompareAndSwap(t *testing.T) {
+	tc := NewPro[int](DefaultExpiration, 0, nil)
+	tc.Set("a", 1, DefaultExpiration)
+
+	if !CompareAndSwap(tc, "a", 1, 2, DefaultExpiration) {
+		t.Error("Expected swap to succeed")
+	}
+	if v, _ := tc.Get("a"); v != 2 {
+		t.Errorf("Expected a=2, got %d", v)
+	}
+	if CompareAndSwap(tc, "a", 1, 3, DefaultExpiration) {
+		t.Error("Expected swap with a stale old value to fail")
+	}
+	if v, _ := tc.Get("a"); v != 2 {
+		t.Errorf("Expected a to stay 2, got %d", v)
+	}
+	if CompareAndSwap(tc, "missing", 0, 1, DefaultExpiration) {
+		t.Error("Expected swap on a missing key to fail")
+	}
+	if _, found := tc.Get("missing"); found {
+		t.Error("Failed swap created the missing key")
+	}
+}
+
+// TestSetWithDeadline 测试以绝对时间设置过期
+func TestSetWithDeadline(t *testing.T) {
+	clk := newFakeClock()
+	tc := NewPro[int](DefaultExpiration, 0, nil, withClock[int](clk))
+	deadline := clk.Now().Add(20 * time.Millisecond)
+	tc.SetWithDeadline("a", 1, deadline)
+	tc.SetWithDeadline("b", 2, time.Time{})
+	tc.SetWithDeadline("c", 3, time.Unix(0, 0).Add(-time.Hour))
+
+	_, exp, found := tc.GetWithExpiration("a")
+	if !found || exp.UnixNano() != deadline.UnixNano() {
+		t.Errorf("Expected expiration %v, got %v, %v", deadline, exp, found)
+	}
+	if _, found := tc.Get("c"); found {
+		t.Error("Item with a deadline before 1970 did not expire")
+	}
+
+	clk.Advance(20 * time.Millisecond)
+	if _, found := tc.Get("a"); !found {
+		t.Error("Item expired before its deadline")
+	}
+	clk.Advance(time.Millisecond)
+	if _, found := tc.Get("a"); found {
+		t.Error("Item did not expire after its deadline")
+	}
+	tc.DeleteExpired()
+	if tc.ItemCount() != 1 {
+		t.Errorf("Expected only the non-expiring item to remain, got %d items", tc.ItemCount())
+	}
+	if _, found := tc.Get("b"); !found {
+		t.Error("Item with a zero deadline expired")
+	}
+}
+
+// TestSetExpiration 测试只修改已存在项目的过期时间而不改变其值
+func TestSetExpiration(t *testing.T) {
+	clk := newFakeClock()
+	tc := NewPro[int](DefaultExpiration, 0, nil, withClock[int](clk))
+	tc.Set("a", 1, time.Minute)
+
+	deadline := clk.Now().Add(time.Hour)
+	if !tc.SetExpiration("a", deadline) {
+		t.Fatal("SetExpiration reported a live key as missing")
+	}
+	if v, exp, found := tc.GetWithExpiration("a"); !found || v != 1 || !exp.Equal(deadline) {
+		t.Errorf("Expected 1 expiring at %v, got %d at %v, %v", deadline, v, exp, found)
+	}
+
+	if !tc.SetExpiration("a", time.Time{}) {
+		t.Fatal("SetExpiration reported a live key as missing")
+	}
+	if _, exp, _ := tc.GetWithExpiration("a"); !exp.IsZero() {
+		t.Errorf("Expected no expiration for a zero deadline, got %v", exp)
+	}
+
+	if tc.SetExpiration("absent", deadline) {
+		t.Error("SetExpiration reported an absent key as updated")
+	}
+	tc.Set("expired", 2, time.Second)
+	clk.Advance(2 * time.Second)
+	if tc.SetExpiration("expired", deadline) {
+		t.Error("SetExpiration revived an expired key")
+	}
+}
+
+// TestClone 测试副本与原CachePro相互独立，且不包含已过期的项目
+func TestClone(t *testing.T) {
+	clk := newFakeClock()
+	tc := NewPro[int](time.Minute, 0, nil, withClock[int](clk))
+	tc.Set("a", 1, DefaultExpiration)
+	tc.Set("expired", 2, time.Millisecond)
+	clk.Advance(time.Second)
+
+	cl := tc.Clone()
+	if cl.ItemCount() != 1 {
+		t.Errorf("Expected expired item to be dropped from clone, got %d items", cl.ItemCount())
+	}
+	if v, found := cl.Get("a"); !found || v != 1 {
+		t.Errorf("Expected a=1 in clone, got %d, %v", v, found)
+	}
+
+	cl.Set("a", 10, DefaultExpiration)
+	cl.Set("b", 20, DefaultExpiration)
+	if v, _ := tc.Get("a"); v != 1 {
+		t.Errorf("Writing to the clone changed the original: a=%d", v)
+	}
+	if _, found := tc.Get("b"); found {
+		t.Error("Writing to the clone added b to the original")
+	}
+
+	tc.Delete("a")
+	if _, found := cl.Get("a"); !found {
+		t.Error("Deleting from the original removed a from the clone")
+	}
+
+	// 副本使用相同的默认过期时间
+	clk.Advance(2 * time.Minute)
+	if _, found := cl.Get("b"); found {
+		t.Error("Expected clone to inherit the default expiration")
+	}
+}
+
+// TestGetOrCompute 测试新键只计算一次，已存在的键不计算，计算错误时不存储
+func TestGetOrCompute(t *testing.T) {
+	tc := NewPro[int](DefaultExpiration, 0, nil)
+	var mu sync.Mutex
+	calls := 0
+	compute := func() (int, error) {
+		mu.Lock()
+		calls++
+		mu.Unlock()
+		return 42, nil
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if v, err := tc.GetOrCompute("fresh", compute, DefaultExpiration); err != nil || v != 42 {
+				t.Errorf("Expected 42, got %d, %v", v, err)
+			}
+		}()
+	}
+	wg.Wait()
+	if calls != 1 {
+		t.Errorf("Expected compute to run once, ran %d times", calls)
+	}
+
+	tc.Set("existing", 7, DefaultExpiration)
+	if v, err := tc.GetOrCompute("existing", compute, DefaultExpiration); err != nil || v != 7 {
+		t.Errorf("Expected existing value 7, got %d, %v", v, err)
+	}
+	if calls != 1 {
+		t.Errorf("Expected compute not to run for an existing key, ran %d times", calls)
+	}
+
+	errFail := errors.New("fail")
+	if _, err := tc.GetOrCompute("bad", func() (int, error) { return 1, errFail }, DefaultExpiration); err != errFail {
+		t.Errorf("Expected compute error, got %v", err)
+	}
+	if _, found := tc.Get("bad"); found {
+		t.Error("Failed compute result was stored")
+	}
+}
+
+// TestGetOrSetFunc 测试键存在时不调用f，不存在或已过期时调用f并存储结果
+func TestGetOrSetFunc(t *testing.T) {
+	clk := newFakeClock()
+	tc := NewPro[int](DefaultExpiration, 0, nil, withClock[int](clk))
+	calls := 0
+	f := func() int {
+		calls++
+		return 42
+	}
+
+	tc.Set("existing", 7, DefaultExpiration)
+	if v, loaded := tc.GetOrSetFunc("existing", time.Minute, f); !loaded || v != 7 {
+		t.Errorf("Expected existing value 7, got %d, %v", v, loaded)
+	}
+	if calls != 0 {
+		t.Errorf("Expected f not to run for an existing key, ran %d times", calls)
+	}
+
+	if v, loaded := tc.GetOrSetFunc("fresh", time.Minute, f); loaded || v != 42 {
+		t.Errorf("Expected computed value 42, got %d, %v", v, loaded)
+	}
+	if v, found := tc.Get("fresh"); !found || v != 42 {
+		t.Errorf("Expected the computed value to be stored, got %d, %v", v, found)
+	}
+
+	clk.Advance(2 * time.Minute)
+	if _, loaded := tc.GetOrSetFunc("fresh", time.Minute, f); loaded || calls != 2 {
+		t.Errorf("Expected f to run again for an expired key, ran %d times", calls)
+	}
+}
+
+// TestSetAll 测试按ID字段批量写入结构体切片，重复的键以后出现的为准
+func TestSetAll(t *testing.T) {
+	type user struct {
+		ID   string
+		Name string
+	}
+	tc := NewPro[user](DefaultExpiration, 0, nil)
+	users := []user{{"1", "alice"}, {"2", "bob"}, {"3", "carol"}, {"2", "bobby"}}
+	SetAll(tc, users, func(u user) string { return u.ID }, DefaultExpiration)
+
+	if tc.ItemCount() != 3 {
+		t.Errorf("Expected 3 items, got %d", tc.ItemCount())
+	}
+	for id, name := range map[string]string{"1": "alice", "2": "bobby", "3": "carol"} {
+		if u, found := tc.Get(id); !found || u.Name != name {
+			t.Errorf("Expected %s to be %s, got %+v, %v", id, name, u, found)
+		}
+	}
+}
+
+// TestSetManyWithExpiration 测试批量写入时每个项目使用各自的过期时间
+func TestSetManyWithExpiration(t *testing.T) {
+	clk := newFakeClock()
+	tc := NewPro[string](time.Hour, 0, nil, withClock[string](clk))
+	tc.SetManyWithExpiration(map[string]EntryPro[string]{
+		"session": {Value: "s", D: time.Minute},
+		"config":  {Value: "c", D: NoExpiration},
+		"default": {Value: "d", D: DefaultExpiration},
+	})
+	if tc.ItemCount() != 3 {
+		t.Fatalf("Expected 3 items, got %d", tc.ItemCount())
+	}
+
+	clk.Advance(2 * time.Minute)
+	if _, found := tc.Get("session"); found {
+		t.Error("session should have expired after 1m")
+	}
+	if _, found := tc.Get("default"); !found {
+		t.Error("default expired before the 1h default expiration")
+	}
+
+	clk.Advance(time.Hour)
+	if _, found := tc.Get("default"); found {
+		t.Error("default should have expired after 1h")
+	}
+	if v, found := tc.Get("config"); !found || v != "c" {
+		t.Error("config should never expire")
+	}
+}
+
+// TestSetMaxTTL 测试过期时间被限制在最长存活时间内，包括永不过期的项目
+func TestSetMaxTTL(t *testing.T) {
+	clk := newFakeClock()
+	tc := NewPro[int](DefaultExpiration, 0, nil, withClock[int](clk))
+	tc.SetMaxTTL(time.Hour)
+
+	tc.Set("long", 1, 10*time.Hour)
+	tc.Set("forever", 2, NoExpiration)
+	tc.Set("short", 3, time.Minute)
+	tc.Compute("computed", func(a, b int) int { return a + b }, 0)
+	tc.SetWithDeadline("deadline", 4, clk.Now().Add(24*time.Hour))
+
+	want := clk.Now().Add(time.Hour)
+	for _, k := range []string{"long", "forever", "computed", "deadline"} {
+		_, exp, found := tc.GetWithExpiration(k)
+		if !found || !exp.Equal(want) {
+			t.Errorf("Expected %s to expire at %v, got %v, %v", k, want, exp, found)
+		}
+	}
+	if _, exp, _ := tc.GetWithExpiration("short"); !exp.Equal(clk.Now().Add(time.Minute)) {
+		t.Errorf("Expected short TTL to be unaffected, got %v", exp)
+	}
+
+	tc.SetMaxTTL(0)
+	tc.Set("forever", 2, NoExpiration)
+	if _, exp, _ := tc.GetWithExpiration("forever"); !exp.IsZero() {
+		t.Errorf("Expected no expiration after removing the cap, got %v", exp)
+	}
+}
+
+// TestGetMultiWithMisses 测试同时返回找到的项目和未找到的键
+func TestGetMultiWithMisses(t *testing.T) {
+	clk := newFakeClock()
+	tc := NewPro[int](DefaultExpiration, 0, nil, withClock[int](clk))
+	tc.Set("present", 1, DefaultExpiration)
+	tc.Set("expired", 2, time.Second)
+	tc.Set("other", 3, DefaultExpiration)
+	clk.Advance(2 * time.Second)
+
+	found, missing := tc.GetMultiWithMisses([]string{"absent", "present", "expired", "other"})
+	if len(found) != 2 || found["present"] != 1 || found["other"] != 3 {
+		t.Errorf("Unexpected found items: %v", found)
+	}
+	if len(missing) != 2 || missing[0] != "absent" || missing[1] != "expired" {
+		t.Errorf("Expected missing [absent expired], got %v", missing)
+	}
+}
+
+// TestGetManyWithExpiration 测试批量获取时返回的过期时间与写入时一致，并忽略不存在和已过期的键
+func TestGetManyWithExpiration(t *testing.T) {
+	clk := newFakeClock()
+	tc := NewPro[int](DefaultExpiration, 0, nil, withClock[int](clk))
+	tc.Set("minute", 1, time.Minute)
+	tc.Set("hour", 2, time.Hour)
+	tc.Set("forever", 3, NoExpiration)
+	tc.Set("expired", 4, time.Second)
+	clk.Advance(2 * time.Second)
+
+	items := tc.GetManyWithExpiration([]string{"minute", "hour", "forever", "expired", "absent"})
+	if len(items) != 3 {
+		t.Fatalf("Expected 3 items, got %d", len(items))
+	}
+	start := clk.Now().Add(-2 * time.Second)
+	if got := items["minute"]; got.Object != 1 || got.Expiration != start.Add(time.Minute).UnixNano() {
+		t.Errorf("Unexpected item for minute: %+v", got)
+	}
+	if got := items["hour"]; got.Object != 2 || got.Expiration != start.Add(time.Hour).UnixNano() {
+		t.Errorf("Unexpected item for hour: %+v", got)
+	}
+	if got := items["forever"]; got.Object != 3 || got.Expiration != 0 {
+		t.Errorf("Unexpected item for forever: %+v", got)
+	}
+}
+
+// TestLoadMergeNewer 测试键冲突时保留过期时间较晚的项目
+func TestLoadMergeNewer(t *testing.T) {
+	src := NewPro[string](DefaultExpiration, 0, nil)
+	src.Set("memoryNewer", "loaded", time.Minute)
+	src.Set("loadedNewer", "loaded", time.Hour)
+	src.Set("loadedForever", "loaded", NoExpiration)
+	src.Set("absent", "loaded", time.Minute)
+	buf := &bytes.Buffer{}
+	if err := src.Save(buf); err != nil {
+		t.Fatal(err)
+	}
+
+	tc := NewPro[string](DefaultExpiration, 0, nil)
+	tc.Set("memoryNewer", "memory", time.Hour)
+	tc.Set("loadedNewer", "memory", time.Minute)
+	tc.Set("loadedForever", "memory", time.Hour)
+	if err := tc.LoadMergeNewer(buf); err != nil {
+		t.Fatalf("LoadMergeNewer failed: %v", err)
+	}
+
+	for k, want := range map[string]string{
+		"memoryNewer":   "memory",
+		"loadedNewer":   "loaded",
+		"loadedForever": "loaded",
+		"absent":        "loaded",
+	} {
+		if v, _ := tc.Get(k); v != want {
+			t.Errorf("Expected %s to be %q, got %q", k, want, v)
+		}
+	}
+}
+
+// TestMerge 测试合并另一个CachePro时的各种冲突处理方式
+func TestMerge(t *testing.T) {
+	clk := newFakeClock()
+	newCaches := func() (*CachePro[string], *CachePro[string]) {
+		mine := NewPro[string](DefaultExpiration, 0, nil, withClock[string](clk))
+		mine.Set("mineLater", "mine", time.Hour)
+		mine.Set("theirsLater", "mine", time.Minute)
+		mine.Set("theirsForever", "mine", time.Hour)
+		mine.Set("tie", "mine", time.Minute)
+		mine.Set("mineExpired", "mine", time.Second)
+
+		theirs := NewPro[string](DefaultExpiration, 0, nil, withClock[string](clk))
+		theirs.Set("mineLater", "theirs", time.Minute)
+		theirs.Set("theirsLater", "theirs", time.Hour)
+		theirs.Set("theirsForever", "theirs", NoExpiration)
+		theirs.Set("tie", "theirs", time.Minute)
+		theirs.Set("mineExpired", "theirs", time.Minute)
+		theirs.Set("absent", "theirs", time.Minute)
+		theirs.Set("theirsExpired", "theirs", time.Second)
+		return mine, theirs
+	}
+
+	tests := []struct {
+		conflict ConflictPolicy
+		want     map[string]string
+	}{
+		{KeepMine, map[string]string{
+			"mineLater": "mine", "theirsLater": "mine", "theirsForever": "mine", "tie": "mine",
+		}},
+		{KeepTheirs, map[string]string{
+			"mineLater": "theirs", "theirsLater": "theirs", "theirsForever": "theirs", "tie": "theirs",
+		}},
+		{KeepLaterExpiration, map[string]string{
+			"mineLater": "mine", "theirsLater": "theirs", "theirsForever": "theirs", "tie": "mine",
+		}},
+	}
+	for _, tt := range tests {
+		mine, theirs := newCaches()
+		clk.Advance(2 * time.Second)
+		mine.Merge(theirs, tt.conflict)
+
+		tt.want["mineExpired"] = "theirs"
+		tt.want["absent"] = "theirs"
+		for k, want := range tt.want {
+			if v, _ := mine.Get(k); v != want {
+				t.Errorf("Policy %d: expected %s to be %q, got %q", tt.conflict, k, want, v)
+			}
+		}
+		if _, found := mine.Get("theirsExpired"); found {
+			t.Errorf("Policy %d: an expired item was merged", tt.conflict)
+		}
+		if theirs.ItemCount() != 7 {
+			t.Errorf("Policy %d: merging modified the other cache", tt.conflict)
+		}
+	}
+
+	// 精确的过期时间被保留
+	mine, theirs := newCaches()
+	mine.Merge(theirs, KeepTheirs)
+	_, want, _ := theirs.GetWithExpiration("theirsLater")
+	if _, got, _ := mine.GetWithExpiration("theirsLater"); !got.Equal(want) {
+		t.Errorf("Expected expiration %v, got %v", want, got)
+	}
+	mine.Merge(mine, KeepTheirs)
+}
+
+// TestMergeConcurrent 测试两个CachePro互相合并时不会死锁
+func TestMergeConcurrent(t *testing.T) {
+	a := NewPro[int](DefaultExpiration, 0, nil)
+	b := NewPro[int](DefaultExpiration, 0, nil)
+	a.Set("a", 1, DefaultExpiration)
+	b.Set("b", 2, DefaultExpiration)
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			a.Merge(b, KeepMine)
+		}()
+		go func() {
+			defer wg.Done()
+			b.Merge(a, KeepMine)
+		}()
+	}
+	wg.Wait()
+	if a.ItemCount() != 2 || b.ItemCount() != 2 {
+		t.Errorf("Expected both caches to hold 2 items, got %d and %d", a.ItemCount(), b.ItemCount())
+	}
+}
+
+// TestClose 测试关闭时对所有项目调用delFunc，之后的写入panic，重复关闭不执行任何操作
+func TestClose(t *testing.T) {
+	var deleted []int
+	tc := NewPro[int](DefaultExpiration, time.Millisecond, func(v int) {
+		deleted = append(deleted, v)
+	})
+	for i := 0; i < 5; i++ {
+		tc.Set(strconv.Itoa(i), i, DefaultExpiration)
+	}
+
+	tc.Close()
+	if len(deleted) != 5 {
+		t.Errorf("Expected delFunc for all 5 items, got %v", deleted)
+	}
+	if _, found := tc.Get("1"); found {
+		t.Error("Found an item after Close")
+	}
+	tc.Close()
+	if len(deleted) != 5 {
+		t.Error("Closing twice invoked delFunc again")
+	}
+	tc.SetCleanupInterval(time.Millisecond)
+	if tc.janitor != nil {
+		t.Error("Janitor was restarted after Close")
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("Expected Set to panic after Close")
+		}
+	}()
+	tc.Set("a", 1, DefaultExpiration)
+}
+
+// TestCloseWritePanicUnlocks 测试关闭后写入panic时不会持有锁，恢复之后的读写和事务不会死锁
+func TestCloseWritePanicUnlocks(t *testing.T) {
+	tc := NewPro[int](DefaultExpiration, 0, nil)
+	other := NewPro[int](DefaultExpiration, 0, nil)
+	other.Set("a", 1, DefaultExpiration)
+	tc.Close()
+
+	for name, f := range map[string]func(){
+		"Set":         func() { tc.Set("a", 1, DefaultExpiration) },
+		"Compute":     func() { tc.Compute("a", func(v, d int) int { return v + d }, 1) },
+		"Merge":       func() { tc.Merge(other, KeepTheirs) },
+		"Transaction": func() { tc.Transaction(func(tx *Tx[int]) { tx.Set("a", 1, DefaultExpiration) }) },
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("Expected %s to panic after Close", name)
+				}
+			}()
+			f()
+		}()
+	}
+
+	done := make(chan struct{})
+	go func() {
+		tc.Get("a")
+		tc.Delete("a")
+		other.Get("a")
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Calls after a recovered write panic deadlocked")
+	}
+}
+
+// TestCloseOnFinalize 测试启用WithCloseOnFinalize时CachePro被回收后对剩余项目调用delFunc
+func TestCloseOnFinalize(t *testing.T) {
+	deleted := make(chan int, 3)
+	func() {
+		tc := NewPro[int](DefaultExpiration, time.Hour, func(v int) {
+			deleted <- v
+		}, WithCloseOnFinalize[int]())
+		for i := 0; i < 3; i++ {
+			tc.Set(strconv.Itoa(i), i, DefaultExpiration)
+		}
+	}()
+
+	deadline := time.After(5 * time.Second)
+	for n := 0; n < 3; {
+		runtime.GC()
+		select {
+		case <-deleted:
+			n++
+		case <-time.After(10 * time.Millisecond):
+		case <-deadline:
+			t.Fatalf("Expected delFunc for 3 items after finalization, got %d", n)
+		}
+	}
+}
+
+// TestDelFuncAllPaths 测试删除、过期、覆盖、清空和内存上限驱逐都对每个被移除的值恰好调用一次delFunc
+func TestDelFuncAllPaths(t *testing.T) {
+	clk := newFakeClock()
+	released := map[int]int{}
+	tc := NewPro[int](DefaultExpiration, 0, nil, withClock[int](clk))
+	tc.SetDelFunc(func(v int) {
+		released[v]++
+	})
+
+	tc.Set("deleted", 1, DefaultExpiration)
+	tc.Delete("deleted")
+
+	tc.Set("expired", 2, time.Second)
+	clk.Advance(2 * time.Second)
+	tc.DeleteExpired()
+
+	tc.Set("overwritten", 3, DefaultExpiration)
+	tc.Set("overwritten", 4, DefaultExpiration)
+
+	tc.Set("computed", 10, DefaultExpiration)
+	tc.Compute("computed", func(a, b int) int { return a + b }, 0)
+	Increment(tc, "computed", 1)
+	tc.SetExpiration("computed", clk.Now().Add(time.Hour))
+
+	tc.Set("flushed", 5, DefaultExpiration)
+	tc.SetNegative("negative", DefaultExpiration)
+	tc.Flush()
+
+	want := map[int]int{1: 1, 2: 1, 3: 1, 4: 1, 5: 1, 21: 1}
+	if len(released) != len(want) {
+		t.Errorf("Expected delFunc for %v, got %v", want, released)
+	}
+	for v, n := range want {
+		if released[v] != n {
+			t.Errorf("Expected delFunc to run %d times for %d, ran %d times", n, v, released[v])
+		}
+	}
+
+	tc.SetDelFunc(nil)
+	tc.Set("a", 6, DefaultExpiration)
+	tc.Delete("a")
+	if released[6] != 0 {
+		t.Error("delFunc ran after being disabled")
+	}
+}
+
+// TestDelFuncMemLimit 测试内存上限驱逐对每个被驱逐的值调用一次delFunc
+func TestDelFuncMemLimit(t *testing.T) {
+	released := map[int]int{}
+	tc := NewProWithMemLimit[int](DefaultExpiration, 0, 30, func(int) int64 { return 10 }, func(v int) {
+		released[v]++
+	})
+	for i := 0; i < 5; i++ {
+		tc.Set(strconv.Itoa(i), i, DefaultExpiration)
+	}
+	if tc.ItemCount() != 3 {
+		t.Fatalf("Expected 3 items within the limit, got %d", tc.ItemCount())
+	}
+	if len(released) != 2 || released[0] != 1 || released[1] != 1 {
+		t.Errorf("Expected delFunc once for the 2 evicted values, got %v", released)
+	}
+}
+
+// TestFlushWithCallback 测试清空时对每个项目调用一次delFunc和onEvicted
+func TestFlushWithCallback(t *testing.T) {
+	deleted := map[int]int{}
+	tc := NewPro[int](DefaultExpiration, 0, func(v int) {
+		deleted[v]++
+	})
+	evicted := 0
+	tc.OnEvicted(func(string, interface{}) {
+		evicted++
+	})
+	for i := 0; i < 5; i++ {
+		tc.Set(strconv.Itoa(i), i, DefaultExpiration)
+	}
+
+	tc.FlushWithCallback()
+	if tc.ItemCount() != 0 {
+		t.Errorf("Expected empty cache, got %d items", tc.ItemCount())
+	}
+	if len(deleted) != 5 || evicted != 5 {
+		t.Errorf("Expected 5 delFunc and onEvicted calls, got %v and %d", deleted, evicted)
+	}
+	for v, n := range deleted {
+		if n != 1 {
+			t.Errorf("Expected delFunc to run once for %d, ran %d times", v, n)
+		}
+	}
+
+	tc.FlushWithCallback()
+	if len(deleted) != 5 || evicted != 5 {
+		t.Error("Flushing an empty cache invoked callbacks")
+	}
+}
+
+// TestItemsFiltered 测试按前缀筛选项目，并跳过已过期的项目
+func TestItemsFiltered(t *testing.T) {
+	clk := newFakeClock()
+	tc := NewPro[int](DefaultExpiration, 0, nil, withClock[int](clk))
+	tc.Set("user:1", 1, DefaultExpiration)
+	tc.Set("user:2", 2, DefaultExpiration)
+	tc.Set("user:3", 3, time.Second)
+	tc.Set("page:1", 4, DefaultExpiration)
+	clk.Advance(2 * time.Second)
+
+	items := tc.ItemsFiltered(func(k string, item ItemPro[int]) bool {
+		return strings.HasPrefix(k, "user:")
+	})
+	if len(items) != 2 || items["user:1"].Object != 1 || items["user:2"].Object != 2 {
+		t.Errorf("Expected user:1 and user:2, got %v", items)
+	}
+
+	items = tc.ItemsFiltered(func(k string, item ItemPro[int]) bool {
+		return item.Object > 1
+	})
+	if len(items) != 2 {
+		t.Errorf("Expected user:2 and page:1, got %v", items)
+	}
+}
+
+// TestSetIfVersion 测试版本号匹配时写入成功，过期的版本号被拒绝
+func TestSetIfVersion(t *testing.T) {
+	tc := NewPro[string](DefaultExpiration, 0, nil)
+	if !tc.SetIfVersion("a", "first", 0, DefaultExpiration) {
+		t.Fatal("Expected version 0 to match a missing key")
+	}
+	v, ver, found := tc.GetVersioned("a")
+	if !found || v != "first" || ver == 0 {
+		t.Fatalf("Expected first with a version, got %q, %d, %v", v, ver, found)
+	}
+
+	if !tc.SetIfVersion("a", "second", ver, DefaultExpiration) {
+		t.Error("Expected write with the current version to succeed")
+	}
+	if tc.SetIfVersion("a", "stale", ver, DefaultExpiration) {
+		t.Error("Expected write with a stale version to be rejected")
+	}
+	if tc.SetIfVersion("a", "stale", 0, DefaultExpiration) {
+		t.Error("Expected version 0 not to match an existing key")
+	}
+	v, newVer, _ := tc.GetVersioned("a")
+	if v != "second" || newVer <= ver {
+		t.Errorf("Expected second with a newer version, got %q, %d", v, newVer)
+	}
+
+	tc.Compute("a", func(a, b string) string { return a + "!" }, "")
+	if _, ver, _ := tc.GetVersioned("a"); ver <= newVer {
+		t.Errorf("Expected Compute to bump the version, got %d", ver)
+	}
+}
+
+// TestExpireMatching 测试按前缀立即过期项目，并在清理时触发OnExpired
+func TestExpireMatching(t *testing.T) {
+	tc := NewPro[int](DefaultExpiration, 0, nil)
+	expired := map[string]int{}
+	tc.OnExpired(func(k string, v int) {
+		expired[k] = v
+	})
+	tc.Set("tenant1:a", 1, DefaultExpiration)
+	tc.Set("tenant1:b", 2, time.Hour)
+	tc.Set("tenant2:a", 3, DefaultExpiration)
+
+	n := tc.ExpireMatching(func(k string) bool {
+		return strings.HasPrefix(k, "tenant1:")
+	})
+	if n != 2 {
+		t.Errorf("Expected 2 items to be expired, got %d", n)
+	}
+	if _, found := tc.Get("tenant1:a"); found {
+		t.Error("tenant1:a should miss after ExpireMatching")
+	}
+	if _, found := tc.Get("tenant1:b"); found {
+		t.Error("tenant1:b should miss after ExpireMatching")
+	}
+	if _, found := tc.Get("tenant2:a"); !found {
+		t.Error("tenant2:a should not be affected")
+	}
+	if tc.ItemCount() != 3 {
+		t.Errorf("Expected expired items to remain until swept, got %d items", tc.ItemCount())
+	}
+
+	tc.DeleteExpired()
+	if len(expired) != 2 || expired["tenant1:b"] != 2 {
+		t.Errorf("Expected OnExpired for both tenant1 items, got %v", expired)
+	}
+	if n := tc.ExpireMatching(func(string) bool { return true }); n != 1 {
+		t.Errorf("Expected only the live item to be counted, got %d", n)
+	}
+}
+
+// 编码时总是出错的类型，用于模拟Save写入中途失败
+type failingGob struct{ n int }
+
+func (failingGob) GobEncode() ([]byte, error) {
+	return nil, errors.New("encode failed")
+}
+
+// TestSaveFileAtomic 测试原子保存成功后可以读回，失败时原有文件保持不变且不留下临时文件
+func TestSaveFileAtomic(t *testing.T) {
+	dir := t.TempDir()
+	fname := filepath.Join(dir, "cache.gob")
+
+	tc := NewPro[int](DefaultExpiration, 0, nil)
+	tc.Set("a", 1, DefaultExpiration)
+	if err := tc.SaveFileAtomic(fname); err != nil {
+		t.Fatalf("SaveFileAtomic failed: %v", err)
+	}
+	restored := NewPro[int](DefaultExpiration, 0, nil)
+	if err := restored.LoadFile(fname); err != nil {
+		t.Fatalf("LoadFile failed: %v", err)
+	}
+	if v, found := restored.Get("a"); !found || v != 1 {
+		t.Errorf("Expected a to be 1 after loading, got %d, %v", v, found)
+	}
+
+	before, err := os.ReadFile(fname)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bad := NewPro[failingGob](DefaultExpiration, 0, nil)
+	bad.Set("x", failingGob{1}, DefaultExpiration)
+	if err := bad.SaveFileAtomic(fname); err == nil {
+		t.Fatal("Expected an error when the items cannot be encoded")
+	}
+	after, err := os.ReadFile(fname)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(before, after) {
+		t.Error("The existing file was modified by a failed save")
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("Expected the temporary file to be removed, got %d files", len(entries))
+	}
+}
+
+// TestSnapshotSize 测试快照大小与Save写出的字节数一致，且项目越多快照越大
+func TestSnapshotSize(t *testing.T) {
+	small := NewPro[string](DefaultExpiration, 0, nil)
+	small.Set("a", "x", DefaultExpiration)
+	large := NewPro[string](DefaultExpiration, 0, nil)
+	for i := 0; i < 100; i++ {
+		large.Set(strconv.Itoa(i), strings.Repeat("x", i), DefaultExpiration)
+	}
+
+	smallSize, err := small.SnapshotSize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	largeSize, err := large.SnapshotSize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if largeSize <= smallSize {
+		t.Errorf("Expected the larger cache to report a larger size, got %d <= %d", largeSize, smallSize)
+	}
+	buf := &bytes.Buffer{}
+	if err := large.Save(buf); err != nil {
+		t.Fatal(err)
+	}
+	if int64(buf.Len()) != largeSize {
+		t.Errorf("Expected SnapshotSize %d to match Save output %d", largeSize, buf.Len())
+	}
+}
+
+// TestSaveFileCompressed 测试压缩快照可以读回，且对重复度高的数据比未压缩的快照小
+func TestSaveFileCompressed(t *testing.T) {
+	dir := t.TempDir()
+	plain := filepath.Join(dir, "cache.gob")
+	compressed := filepath.Join(dir, "cache.gob.gz")
+
+	tc := NewPro[string](DefaultExpiration, 0, nil)
+	for i := 0; i < 100; i++ {
+		tc.Set(strconv.Itoa(i), strings.Repeat(`{"status":"ok"}`, 20), DefaultExpiration)
+	}
+	if err := tc.SaveFile(plain); err != nil {
+		t.Fatal(err)
+	}
+	if err := tc.SaveFileCompressed(compressed); err != nil {
+		t.Fatalf("SaveFileCompressed failed: %v", err)
+	}
+
+	restored := NewPro[string](DefaultExpiration, 0, nil)
+	if err := restored.LoadFileCompressed(compressed); err != nil {
+		t.Fatalf("LoadFileCompressed failed: %v", err)
+	}
+	if restored.ItemCount() != 100 {
+		t.Errorf("Expected 100 items after loading, got %d", restored.ItemCount())
+	}
+	if v, _ := restored.Get("42"); v != strings.Repeat(`{"status":"ok"}`, 20) {
+		t.Errorf("Unexpected value after loading: %q", v)
+	}
+
+	plainInfo, err := os.Stat(plain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	compressedInfo, err := os.Stat(compressed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if compressedInfo.Size() >= plainInfo.Size() {
+		t.Errorf("Expected the compressed file to be smaller, got %d >= %d", compressedInfo.Size(), plainInfo.Size())
+	}
+}
+
+// TestReplaceKeepTTL 测试替换值时过期时间完全不变，键不存在或已过期时返回错误
+func TestReplaceKeepTTL(t *testing.T) {
+	clk := newFakeClock()
+	tc := NewPro[string](DefaultExpiration, 0, nil, withClock[string](clk))
+	tc.Set("a", "old", time.Minute)
+	before := tc.Items()["a"].Expiration
+
+	clk.Advance(30 * time.Second)
+	if err := tc.ReplaceKeepTTL("a", "new"); err != nil {
+		t.Fatalf("ReplaceKeepTTL failed: %v", err)
+	}
+	if v, _ := tc.Get("a"); v != "new" {
+		t.Errorf("Expected 'new', got %q", v)
+	}
+	if after := tc.Items()["a"].Expiration; after != before {
+		t.Errorf("Expected expiration %d to be unchanged, got %d", before, after)
+	}
+
+	if err := tc.ReplaceKeepTTL("absent", "x"); err == nil {
+		t.Error("Expected an error for an absent key")
+	}
+	clk.Advance(time.Minute)
+	if err := tc.ReplaceKeepTTL("a", "newer"); err == nil {
+		t.Error("Expected an error for an expired key")
+	}
+}
+
+// TestTryAdd 测试多个goroutine同时抢占同一个键时只有一个成功
+func TestTryAdd(t *testing.T) {
+	tc := NewPro[int](DefaultExpiration, 0, nil)
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	winners := []int{}
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if tc.TryAdd("lock", i, time.Minute) {
+				mu.Lock()
+				winners = append(winners, i)
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	if len(winners) != 1 {
+		t.Fatalf("Expected exactly one winner, got %d", len(winners))
+	}
+	if v, _ := tc.Get("lock"); v != winners[0] {
+		t.Errorf("Expected the winner's value %d to be stored, got %d", winners[0], v)
+	}
+}
+
+// TestUpdateAll 测试一次性将所有整数值减半并保留过期时间
+func TestUpdateAll(t *testing.T) {
+	tc := NewPro[int](DefaultExpiration, 0, nil)
+	tc.Set("a", 10, DefaultExpiration)
+	tc.Set("b", 20, time.Hour)
+	tc.Set("c", 30, DefaultExpiration)
+	_, expBefore, _ := tc.GetWithExpiration("b")
+
+	tc.UpdateAll(func(k string, old int) (int, bool) {
+		return old / 2, true
+	})
+	for k, want := range map[string]int{"a": 5, "b": 10, "c": 15} {
+		if v, found := tc.Get(k); !found || v != want {
+			t.Errorf("Expected %s to be %d, got %d, %v", k, want, v, found)
+		}
+	}
+	if _, expAfter, _ := tc.GetWithExpiration("b"); !expAfter.Equal(expBefore) {
+		t.Errorf("Expected expiration %v to be preserved, got %v", expBefore, expAfter)
+	}
+}
+
+// TestUpdateAllPrune 测试删除低于阈值的值
+func TestUpdateAllPrune(t *testing.T) {
+	var deleted []int
+	tc := NewPro[int](DefaultExpiration, 0, func(v int) {
+		deleted = append(deleted, v)
+	})
+	for i := 1; i <= 5; i++ {
+		tc.Set(strconv.Itoa(i), i, DefaultExpiration)
+	}
+	tc.UpdateAll(func(k string, old int) (int, bool) {
+		return old, old >= 3
+	})
+	if tc.ItemCount() != 3 {
+		t.Errorf("Expected 3 items to remain, got %d", tc.ItemCount())
+	}
+	if _, found := tc.Get("2"); found {
+		t.Error("Value below the threshold was not pruned")
+	}
+	if len(deleted) != 2 {
+		t.Errorf("Expected delFunc for 2 pruned items, got %v", deleted)
+	}
+}
+
+// TestNewFromProWithDelFunc 测试恢复的项目被清理程序删除时调用delFunc
+func TestNewFromProWithDelFunc(t *testing.T) {
+	exp := time.Now().Add(5 * time.Millisecond).UnixNano()
+	items := map[string]ItemPro[int]{
+		"a": {Object: 1, Expiration: exp},
+		"b": {Object: 2, Expiration: exp},
+		"c": {Object: 3},
+	}
+	var mu sync.Mutex
+	var deleted []int
+	tc := NewFromProWithDelFunc[int](DefaultExpiration, time.Millisecond, items, func(v int) {
+		mu.Lock()
+		deleted = append(deleted, v)
+		mu.Unlock()
+	})
+	defer tc.SetCleanupInterval(0)
+
+	deadline := time.Now().Add(time.Second)
+	for tc.ItemCount() > 1 && time.Now().Before(deadline) {
+		<-time.After(time.Millisecond)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(deleted) != 2 {
+		t.Errorf("Expected delFunc for the 2 restored expiring items, got %v", deleted)
+	}
+	if _, found := tc.Get("c"); !found {
+		t.Error("c should never expire")
+	}
+}
+
+type gobPoint struct{ X, Y int }
+
+type gobLabel struct{ Text string }
+
+// TestSaveLoadInterfaceTypes 测试保存和载入保存了多种具体类型的CachePro[interface{}]
+func TestSaveLoadInterfaceTypes(t *testing.T) {
+	RegisterGobTypes(gobPoint{}, gobLabel{})
+	tc := NewPro[interface{}](DefaultExpiration, 0, nil)
+	tc.Set("point", gobPoint{1, 2}, DefaultExpiration)
+	tc.Set("label", gobLabel{"hello"}, DefaultExpiration)
+
+	buf := &bytes.Buffer{}
+	if err := tc.Save(buf); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	oc := NewPro[interface{}](DefaultExpiration, 0, nil)
+	if err := oc.Load(buf); err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if v, _ := oc.Get("point"); v != (gobPoint{1, 2}) {
+		t.Errorf("Expected gobPoint{1, 2}, got %#v", v)
+	}
+	if v, _ := oc.Get("label"); v != (gobLabel{"hello"}) {
+		t.Errorf("Expected gobLabel{hello}, got %#v", v)
+	}
+}
+
+// TestSaveUnencodable 测试编码错误被原样返回
+func TestSaveUnencodable(t *testing.T) {
+	tc := NewPro[interface{}](DefaultExpiration, 0, nil)
+	tc.Set("chan", make(chan bool), DefaultExpiration)
+	err := tc.Save(&bytes.Buffer{})
+	if err == nil || !strings.Contains(err.Error(), "chan bool") {
+		t.Errorf("Expected the underlying gob error, got %v", err)
+	}
+}
@@ -2,6 +2,7 @@ package cache
 
 import (
 	"bytes"
+	"errors"
 	"io/ioutil"
 	"runtime"
 	"strconv"
@@ -1122,6 +1123,9 @@ func TestAdd(t *testing.T) {
 	if err == nil {
 		t.Error("Successfully added another foo when it should have returned an error")
 	}
+	if !errors.Is(err, ErrKeyExists) {
+		t.Errorf("Expected ErrKeyExists, got %v", err)
+	}
 }
 
 func TestReplace(t *testing.T) {
@@ -1130,6 +1134,9 @@ func TestReplace(t *testing.T) {
 	if err == nil {
 		t.Error("Replaced foo when it shouldn't exist")
 	}
+	if !errors.Is(err, ErrKeyNotFound) {
+		t.Errorf("Expected ErrKeyNotFound, got %v", err)
+	}
 	tc.Set("foo", "bar", DefaultExpiration)
 	err = tc.Replace("foo", "bar", DefaultExpiration)
 	if err != nil {
@@ -0,0 +1,32 @@
+package cache
+
+import (
+	"time"
+)
+
+// 提供当前时间，测试中可以替换为可手动推进的时钟
+type clock interface {
+	Now() time.Time
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time { return time.Now() }
+
+// 使用给定的时钟代替系统时间，仅供测试使用
+// 清理程序的触发间隔仍使用真实时间，只有过期判断使用该时钟
+func withClock[T any](clk clock) OptionPro[T] {
+	return func(c *cachePro[T]) {
+		c.clock = clk
+	}
+}
+
+// 如果项目按CachePro的时钟已过期则返回true
+func (c *cachePro[T]) expired(item ItemPro[T]) bool {
+	return c.expiredAt(item.Expiration)
+}
+
+// 如果过期时间expiration按CachePro的时钟已过去则返回true，0表示永不过期
+func (c *cachePro[T]) expiredAt(expiration int64) bool {
+	return expiration > 0 && c.clock.Now().UnixNano() > expiration
+}
@@ -0,0 +1,60 @@
+package cache
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DumpString最多输出的项目数，以免巨大的CachePro产生巨大的字符串
+const dumpMaxItems = 1000
+
+// String 以"值 (expires in 3s)"的形式返回项目的可读表示，永不过期时为"值 (never)"，
+// 已过期时为"值 (expired 3s ago)"，负缓存项目的值显示为<negative>。仅用于调试和日志
+func (item ItemPro[T]) String() string {
+	return item.format(time.Now().UnixNano())
+}
+
+// 以now为当前时间返回项目的可读表示
+func (item ItemPro[T]) format(now int64) string {
+	var v string
+	if item.Negative {
+		v = "<negative>"
+	} else {
+		v = fmt.Sprintf("%v", item.Object)
+	}
+	switch {
+	case item.Expiration <= 0:
+		return v + " (never)"
+	case now > item.Expiration:
+		return fmt.Sprintf("%s (expired %s ago)", v, time.Duration(now-item.Expiration).Round(time.Millisecond))
+	default:
+		return fmt.Sprintf("%s (expires in %s)", v, time.Duration(item.Expiration-now).Round(time.Millisecond))
+	}
+}
+
+// DumpString 返回所有未过期项目的多行可读表示，每行为"键: 值 (过期时间)"，按键排序
+// 最多输出dumpMaxItems个项目，其余的以一行省略说明代替。仅用于调试，不要依赖其格式
+func (c *CachePro[T]) DumpString() string {
+	c.mu.RLock()
+	now := c.clock.Now().UnixNano()
+	keys := make([]string, 0, len(c.items))
+	for k, v := range c.items {
+		if v.Expiration > 0 && now > v.Expiration {
+			continue
+		}
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var b strings.Builder
+	for i, k := range keys {
+		if i == dumpMaxItems {
+			fmt.Fprintf(&b, "... and %d more\n", len(keys)-dumpMaxItems)
+			break
+		}
+		fmt.Fprintf(&b, "%s: %s\n", k, c.items[k].format(now))
+	}
+	c.mu.RUnlock()
+	return b.String()
+}
@@ -0,0 +1,59 @@
+package cache
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestItemProString 测试项目的可读表示
+func TestItemProString(t *testing.T) {
+	now := time.Now().UnixNano()
+	cases := []struct {
+		item ItemPro[int]
+		want string
+	}{
+		{ItemPro[int]{Object: 1}, "1 (never)"},
+		{ItemPro[int]{Object: 2, Expiration: now + int64(3*time.Second)}, "2 (expires in 3s)"},
+		{ItemPro[int]{Object: 3, Expiration: now - int64(time.Minute)}, "3 (expired 1m0s ago)"},
+		{ItemPro[int]{Negative: true}, "<negative> (never)"},
+	}
+	for _, c := range cases {
+		if got := c.item.format(now); got != c.want {
+			t.Errorf("Expected %q, got %q", c.want, got)
+		}
+	}
+}
+
+// TestDumpString 测试输出包含所有未过期的键以及永不过期的标记
+func TestDumpString(t *testing.T) {
+	clk := newFakeClock()
+	tc := NewPro[string](DefaultExpiration, 0, nil, withClock[string](clk))
+	tc.Set("forever", "a", NoExpiration)
+	tc.Set("minute", "b", time.Minute)
+	tc.Set("expired", "c", time.Second)
+	clk.Advance(2 * time.Second)
+
+	dump := tc.DumpString()
+	if !strings.Contains(dump, "forever: a (never)\n") {
+		t.Errorf("Expected a never marker for forever, got:\n%s", dump)
+	}
+	if !strings.Contains(dump, "minute: b (expires in 58s)\n") {
+		t.Errorf("Expected minute with its remaining TTL, got:\n%s", dump)
+	}
+	if strings.Contains(dump, "expired") {
+		t.Errorf("Expired item was dumped:\n%s", dump)
+	}
+
+	for i := 0; i < dumpMaxItems+5; i++ {
+		tc.Set(strconv.Itoa(i), "x", NoExpiration)
+	}
+	dump = tc.DumpString()
+	if lines := strings.Count(dump, "\n"); lines != dumpMaxItems+1 {
+		t.Errorf("Expected %d lines, got %d", dumpMaxItems+1, lines)
+	}
+	if !strings.HasSuffix(dump, "... and 7 more\n") {
+		t.Errorf("Expected a truncation marker, got %q", dump[len(dump)-30:])
+	}
+}
@@ -0,0 +1,100 @@
+package cache
+
+import (
+	"iter"
+	"maps"
+)
+
+// 在读取时删除已过期的项目，而不是等待清理程序：Get、GetWithExpiration、Has和GetEntry
+// 遇到已过期的项目时会获取写锁将其删除，并调用delFunc、onEvicted和OnExpired设置的回调。
+// 适用于清理间隔很长或禁用了清理程序的CachePro，以免已过期的项目一直占用内存。
+// 这会让读取已过期项目的调用获取写锁，因此默认关闭
+func WithLazyEvict[T any]() OptionPro[T] {
+	return func(c *cachePro[T]) {
+		c.lazyEvict = true
+	}
+}
+
+// OnExpired 设置一个（可选的）函数，仅当项目因过期而被删除时调用（DeleteExpired或清理程序，
+// 以及启用WithLazyEvict时的读取），手动删除、覆盖和因内存上限驱逐都不会触发。
+// 与OnEvicted不同，回调的值是T类型。回调在释放锁之后同步调用，负缓存项目过期时不会触发
+// 设置为nil以禁用
+func (c *CachePro[T]) OnExpired(f func(key string, value T)) {
+	c.mu.Lock()
+	c.onExpired = f
+	c.mu.Unlock()
+}
+
+// 如果键k仍然是之前读到的已过期项目，则将其删除并调用回调。调用方不能持有锁
+// 在释放读锁到获取写锁之间，该键可能已被其他goroutine刷新，因此需要在写锁内重新检查
+func (c *cachePro[T]) evictIfExpired(k string) {
+	c.mu.Lock()
+	item, found := c.items[k]
+	if !found || !c.hardExpired(item) {
+		c.mu.Unlock()
+		return
+	}
+	c.unlockAndNotifyExpired(k, item)
+}
+
+// 删除已过期的项目k，释放写锁后调用onEvicted和onExpired。调用方必须持有写锁
+func (c *cachePro[T]) unlockAndNotifyExpired(k string, item ItemPro[T]) {
+	onExpired := c.onExpired
+	v, evicted := c.delete(k)
+	c.mu.Unlock()
+	if evicted {
+		c.notifyEvicted(k, v)
+	}
+	if onExpired != nil && !item.Negative {
+		onExpired(k, item.Object)
+	}
+}
+
+// DeleteExpiredBatched 与DeleteExpired相同，但每次最多在写锁内检查batchSize个项目，
+// 批次之间释放写锁，让其他操作可以穿插执行，适用于项目数量很大、DeleteExpired会长时间阻塞其他操作的CachePro。
+// 批次之间对映射的修改遵循range的语义：扫描期间新写入或刚过期的项目可能会被漏掉，留给下一次清理。
+// 每一批的回调都在释放写锁之后调用。batchSize小于1时按1处理
+func (c *CachePro[T]) DeleteExpiredBatched(batchSize int) {
+	if batchSize < 1 {
+		batchSize = 1
+	}
+	now := c.clock.Now().UnixNano()
+	c.mu.Lock()
+	// 迭代器只在持有写锁时前进，因此可以跨越批次继续扫描同一个映射
+	next, stop := iter.Pull2(maps.All(c.items))
+	c.mu.Unlock()
+	defer stop()
+
+	for done := false; !done; {
+		var evictedItems []keyAndValuePro
+		var expiredItems []keyAndItemPro[T]
+		c.mu.Lock()
+		onExpired := c.onExpired
+		for i := 0; i < batchSize; i++ {
+			k, _, ok := next()
+			if !ok {
+				done = true
+				break
+			}
+			// 映射可能在批次之间被Flush或Load替换，以当前的项目为准
+			v, found := c.items[k]
+			if !found || v.Expiration <= 0 || now <= v.Expiration || now <= v.HardExpiration {
+				continue
+			}
+			ov, evicted := c.delete(k)
+			if evicted {
+				evictedItems = append(evictedItems, keyAndValuePro{k, ov})
+			}
+			if onExpired != nil && !v.Negative {
+				expiredItems = append(expiredItems, keyAndItemPro[T]{k, v})
+			}
+		}
+		c.mu.Unlock()
+		for _, v := range evictedItems {
+			c.notifyEvicted(v.key, v.value)
+		}
+		for _, v := range expiredItems {
+			onExpired(v.key, v.item.Object)
+		}
+	}
+}
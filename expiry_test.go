@@ -0,0 +1,209 @@
+package cache
+
+import (
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// 记录OnExpired和OnEvicted回调
+type expiryRecorder struct {
+	mu      sync.Mutex
+	expired map[string]int
+	evicted map[string]int
+}
+
+func newExpiryRecorder(tc *CachePro[int]) *expiryRecorder {
+	r := &expiryRecorder{expired: map[string]int{}, evicted: map[string]int{}}
+	tc.OnExpired(func(k string, v int) {
+		r.mu.Lock()
+		r.expired[k] = v
+		r.mu.Unlock()
+	})
+	tc.OnEvicted(func(k string, v interface{}) {
+		r.mu.Lock()
+		r.evicted[k] = v.(int)
+		r.mu.Unlock()
+	})
+	return r
+}
+
+// TestOnExpiredDeleteExpired 测试清理过期项目时触发OnExpired，而手动删除不触发
+func TestOnExpiredDeleteExpired(t *testing.T) {
+	clk := newFakeClock()
+	tc := NewPro[int](DefaultExpiration, 0, nil, withClock[int](clk))
+	r := newExpiryRecorder(tc)
+	tc.Set("a", 1, time.Second)
+	tc.Set("b", 2, NoExpiration)
+	tc.SetNegative("neg", time.Second)
+	tc.Delete("b")
+
+	clk.Advance(2 * time.Second)
+	tc.DeleteExpired()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.expired) != 1 || r.expired["a"] != 1 {
+		t.Errorf("Expected only a to be reported as expired, got %v", r.expired)
+	}
+	if _, ok := r.evicted["b"]; !ok {
+		t.Error("Manual delete did not fire onEvicted")
+	}
+}
+
+// TestOnExpiredLazyGet 测试启用WithLazyEvict时读取已过期项目会删除它并触发回调
+func TestOnExpiredLazyGet(t *testing.T) {
+	clk := newFakeClock()
+	tc := NewPro[int](DefaultExpiration, 0, nil, withClock[int](clk), WithLazyEvict[int]())
+	r := newExpiryRecorder(tc)
+	tc.Set("a", 1, time.Second)
+
+	clk.Advance(2 * time.Second)
+	if _, found := tc.Get("a"); found {
+		t.Fatal("Get returned an expired item")
+	}
+	if tc.ItemCount() != 0 {
+		t.Errorf("Expected Get to remove the expired item, got %d items", tc.ItemCount())
+	}
+	r.mu.Lock()
+	if r.expired["a"] != 1 || r.evicted["a"] != 1 {
+		t.Errorf("Expected expired and evicted callbacks for a, got %v, %v", r.expired, r.evicted)
+	}
+	r.mu.Unlock()
+
+	// 未启用时Get不会删除项目
+	nc := NewPro[int](DefaultExpiration, 0, nil, withClock[int](clk))
+	nr := newExpiryRecorder(nc)
+	nc.Set("a", 1, time.Second)
+	clk.Advance(2 * time.Second)
+	nc.Get("a")
+	if nc.ItemCount() != 1 {
+		t.Errorf("Expected Get not to remove items without WithLazyEvict, got %d items", nc.ItemCount())
+	}
+	nr.mu.Lock()
+	if len(nr.expired) != 0 {
+		t.Errorf("Expected no expired callbacks without WithLazyEvict, got %v", nr.expired)
+	}
+	nr.mu.Unlock()
+}
+
+// TestLazyEvictReclaimsOnRead 测试禁用清理程序时，各读取方法都会回收已过期的项目，并调用delFunc
+func TestLazyEvictReclaimsOnRead(t *testing.T) {
+	clk := newFakeClock()
+	var mu sync.Mutex
+	var deleted []int
+	tc := NewPro[int](DefaultExpiration, 0, func(v int) {
+		mu.Lock()
+		deleted = append(deleted, v)
+		mu.Unlock()
+	}, withClock[int](clk), WithLazyEvict[int]())
+	tc.Set("get", 1, time.Second)
+	tc.Set("getexp", 2, time.Second)
+	tc.Set("has", 3, time.Second)
+	tc.Set("entry", 4, time.Second)
+	tc.Set("live", 5, time.Hour)
+
+	clk.Advance(2 * time.Second)
+	tc.Get("get")
+	tc.GetWithExpiration("getexp")
+	tc.Has("has")
+	tc.GetEntry("entry")
+	tc.Get("live")
+
+	if tc.ItemCount() != 1 {
+		t.Errorf("Expected reads to reclaim 4 expired items, got %d items left", tc.ItemCount())
+	}
+	mu.Lock()
+	if len(deleted) != 4 {
+		t.Errorf("Expected delFunc for 4 items, got %v", deleted)
+	}
+	mu.Unlock()
+}
+
+// TestLazyEvictSkipsRefreshed 测试项目在读取检查之后被刷新时不会被删除
+func TestLazyEvictSkipsRefreshed(t *testing.T) {
+	clk := newFakeClock()
+	tc := NewPro[int](DefaultExpiration, 0, nil, withClock[int](clk), WithLazyEvict[int]())
+	tc.Set("a", 1, time.Second)
+	clk.Advance(2 * time.Second)
+
+	// 模拟Get释放读锁后、获取写锁前另一个goroutine刷新了该键
+	tc.Set("a", 2, time.Hour)
+	tc.evictIfExpired("a")
+	if v, found := tc.Get("a"); !found || v != 2 {
+		t.Errorf("Expected refreshed value 2 to survive, got %d, %v", v, found)
+	}
+}
+
+// TestDeleteExpiredBatched 测试分批清理最终删除所有已过期的项目并触发回调
+func TestDeleteExpiredBatched(t *testing.T) {
+	clk := newFakeClock()
+	tc := NewPro[int](DefaultExpiration, 0, nil, withClock[int](clk))
+	r := newExpiryRecorder(tc)
+	for i := 0; i < 1000; i++ {
+		d := time.Second
+		if i%10 == 0 {
+			d = time.Hour
+		}
+		tc.Set(strconv.Itoa(i), i, d)
+	}
+
+	clk.Advance(2 * time.Second)
+	tc.DeleteExpiredBatched(64)
+
+	if tc.ItemCount() != 100 {
+		t.Errorf("Expected 100 live items to remain, got %d", tc.ItemCount())
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.expired) != 900 || len(r.evicted) != 900 {
+		t.Errorf("Expected 900 expired and evicted callbacks, got %d and %d", len(r.expired), len(r.evicted))
+	}
+	if _, ok := r.expired["10"]; ok {
+		t.Error("Live item was reported as expired")
+	}
+}
+
+// 在清理期间不断写入，报告单次写入的最长等待时间
+func benchmarkDeleteExpiredMaxLatency(b *testing.B, sweep func(tc *CachePro[int])) {
+	var worst time.Duration
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		tc := NewPro[int](DefaultExpiration, 0, nil)
+		for j := 0; j < 100000; j++ {
+			tc.Set(strconv.Itoa(j), j, time.Nanosecond)
+		}
+		time.Sleep(time.Millisecond)
+		var done atomic.Bool
+		var wg sync.WaitGroup
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for !done.Load() {
+				start := time.Now()
+				tc.Set("writer", 1, NoExpiration)
+				worst = max(worst, time.Since(start))
+			}
+		}()
+		b.StartTimer()
+		sweep(tc)
+		b.StopTimer()
+		done.Store(true)
+		wg.Wait()
+	}
+	b.ReportMetric(float64(worst.Nanoseconds()), "max-set-ns")
+}
+
+func BenchmarkDeleteExpiredMaxLatency(b *testing.B) {
+	benchmarkDeleteExpiredMaxLatency(b, func(tc *CachePro[int]) {
+		tc.DeleteExpired()
+	})
+}
+
+func BenchmarkDeleteExpiredBatchedMaxLatency(b *testing.B) {
+	benchmarkDeleteExpiredMaxLatency(b, func(tc *CachePro[int]) {
+		tc.DeleteExpiredBatched(1000)
+	})
+}
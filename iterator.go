@@ -0,0 +1,44 @@
+package cache
+
+import (
+	"time"
+)
+
+// CacheIterator 逐个遍历CachePro中的项目，参见CachePro.Iterator
+// CacheIterator不是并发安全的，只能在一个goroutine中使用
+type CacheIterator[T any] struct {
+	c     *CachePro[T]
+	keys  []string
+	next  int
+	key   string
+	value T
+	exp   time.Time
+}
+
+// Iterator 返回一个遍历CachePro中项目的迭代器。创建时在读锁内复制一份未过期的键，
+// 之后每次Next都单独读取一个项目，不会在整个遍历期间持有锁，因此内存占用只与键的数量有关，
+// 而不像Items那样复制所有的值。遍历期间被删除或已过期的键会被跳过，新写入的键不会被遍历
+func (c *CachePro[T]) Iterator() *CacheIterator[T] {
+	return &CacheIterator[T]{c: c, keys: c.Keys()}
+}
+
+// Next 前进到下一个仍然存在且未过期的项目，没有更多项目时返回false
+func (it *CacheIterator[T]) Next() bool {
+	for it.next < len(it.keys) {
+		k := it.keys[it.next]
+		it.keys[it.next] = ""
+		it.next++
+		if v, exp, found := it.c.GetWithExpiration(k); found {
+			it.key, it.value, it.exp = k, v, exp
+			return true
+		}
+	}
+	var zero T
+	it.key, it.value, it.exp = "", zero, time.Time{}
+	return false
+}
+
+// Item 返回当前项目的键、值和过期时间（永不过期时为time.Time的零值），只能在Next返回true之后调用
+func (it *CacheIterator[T]) Item() (string, T, time.Time) {
+	return it.key, it.value, it.exp
+}
@@ -0,0 +1,59 @@
+package cache
+
+import (
+	"strconv"
+	"testing"
+	"time"
+)
+
+// TestIterator 测试遍历大量项目时输出的键与Keys一致
+func TestIterator(t *testing.T) {
+	tc := NewPro[int](DefaultExpiration, 0, nil)
+	for i := 0; i < 10000; i++ {
+		tc.Set(strconv.Itoa(i), i, DefaultExpiration)
+	}
+	tc.Set("hour", -1, time.Hour)
+
+	want := map[string]bool{}
+	for _, k := range tc.Keys() {
+		want[k] = true
+	}
+	got := map[string]bool{}
+	it := tc.Iterator()
+	for it.Next() {
+		k, v, exp := it.Item()
+		if got[k] {
+			t.Fatalf("Key %s emitted twice", k)
+		}
+		got[k] = true
+		if k == "hour" {
+			if v != -1 || exp.IsZero() {
+				t.Errorf("Unexpected item for hour: %d, %v", v, exp)
+			}
+		} else if strconv.Itoa(v) != k || !exp.IsZero() {
+			t.Errorf("Unexpected item for %s: %d, %v", k, v, exp)
+		}
+	}
+	if len(got) != len(want) {
+		t.Fatalf("Expected %d keys, got %d", len(want), len(got))
+	}
+	for k := range want {
+		if !got[k] {
+			t.Errorf("Key %s was not emitted", k)
+		}
+	}
+}
+
+// TestIteratorSkipsDeleted 测试遍历期间被删除的键会被跳过
+func TestIteratorSkipsDeleted(t *testing.T) {
+	tc := NewPro[int](DefaultExpiration, 0, nil)
+	tc.Set("a", 1, DefaultExpiration)
+	tc.Set("b", 2, DefaultExpiration)
+	it := tc.Iterator()
+	tc.Delete("a")
+	tc.Delete("b")
+	if it.Next() {
+		k, _, _ := it.Item()
+		t.Errorf("Expected no items, got %s", k)
+	}
+}
@@ -0,0 +1,170 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// WithLoader的loader报告键不存在时，用于让load不存储结果
+var errLoaderMiss = errors.New("loader miss")
+
+// 一次正在进行中的加载，同一个键的并发调用者共享其结果
+type loadCall[T any] struct {
+	done chan struct{}
+	val  T
+	err  error
+}
+
+// GetOrLoad 从CachePro返回项目，如果项目不存在或已过期则调用loader加载，
+// 并以过期时间d存储加载结果
+//
+// 同一个键的并发调用只会触发一次loader，其余调用者阻塞等待并共享同一个结果。
+// loader在CachePro的后台goroutine池中运行，收到的上下文保留首个调用者ctx中的值但不会随其取消，
+// 因此某个调用者的ctx被取消时，该调用者立即返回ctx.Err()，而不影响其他仍在等待的调用者。
+// 如果loader返回错误，则不会存储任何内容
+func (c *CachePro[T]) GetOrLoad(ctx context.Context, k string, loader func(ctx context.Context) (T, error), d time.Duration) (T, error) {
+	if v, found := c.Get(k); found {
+		return v, nil
+	}
+	call := c.load(k, func() (T, time.Duration, error) {
+		v, err := loader(context.WithoutCancel(ctx))
+		return v, d, err
+	})
+	select {
+	case <-call.done:
+		return call.val, call.err
+	case <-ctx.Done():
+		var zero T
+		return zero, ctx.Err()
+	}
+}
+
+// GetOrComputeRange 返回keys中每个键的值，对不存在或已过期的键调用compute计算并以过期时间d存储
+//
+// 缺失的键会并发计算，并与GetOrLoad共享同一套去重机制，同一个键同时只会计算一次。
+// 计算失败的键不会出现在返回的map中，所有错误会通过errors.Join合并后返回
+func (c *CachePro[T]) GetOrComputeRange(keys []string, d time.Duration, compute func(k string) (T, error)) (map[string]T, error) {
+	res := make(map[string]T, len(keys))
+	calls := make(map[string]*loadCall[T])
+	for _, k := range keys {
+		if _, ok := calls[k]; ok {
+			continue
+		}
+		if v, found := c.Get(k); found {
+			res[k] = v
+			continue
+		}
+		calls[k] = c.load(k, func() (T, time.Duration, error) {
+			v, err := compute(k)
+			return v, d, err
+		})
+	}
+	var errs []error
+	for _, k := range keys {
+		call, ok := calls[k]
+		if !ok {
+			continue
+		}
+		delete(calls, k)
+		<-call.done
+		if call.err != nil {
+			errs = append(errs, fmt.Errorf("Item %s: %w", k, call.err))
+			continue
+		}
+		res[k] = call.val
+	}
+	return res, errors.Join(errs...)
+}
+
+// 返回键k当前的加载任务，如果没有则启动一个新的任务运行fn，
+// fn成功时以其返回的过期时间存储结果
+// CachePro已关闭时不会启动新的任务，返回的任务以ErrClosed失败
+func (c *cachePro[T]) load(k string, fn func() (T, time.Duration, error)) *loadCall[T] {
+	if c.closed.Load() {
+		call := &loadCall[T]{done: make(chan struct{}), err: ErrClosed}
+		close(call.done)
+		return call
+	}
+	c.loadMu.Lock()
+	if call, ok := c.loads[k]; ok {
+		c.loadMu.Unlock()
+		return call
+	}
+	// 在等待loadMu期间，上一次加载可能已经完成并写入了缓存
+	c.mu.RLock()
+	v, found := c.get(k)
+	c.mu.RUnlock()
+	if found {
+		c.loadMu.Unlock()
+		call := &loadCall[T]{done: make(chan struct{}), val: v}
+		close(call.done)
+		return call
+	}
+	call := &loadCall[T]{done: make(chan struct{})}
+	if c.loads == nil {
+		c.loads = make(map[string]*loadCall[T])
+	}
+	c.loads[k] = call
+	c.loadMu.Unlock()
+
+	pool := c.workers
+	if c.loadWorkers != nil {
+		pool = c.loadWorkers
+	}
+	pool.submit(func() {
+		var d time.Duration
+		call.val, d, call.err = fn()
+		c.mu.Lock()
+		if call.err == nil && c.closed.Load() {
+			// 加载期间CachePro被关闭，结果不再存储
+			call.err = ErrClosed
+		}
+		if call.err == nil {
+			c.overwrite(k, c.refreshedItem(k, call.val, d))
+		}
+		c.mu.Unlock()
+		c.loadMu.Lock()
+		delete(c.loads, k)
+		c.loadMu.Unlock()
+		close(call.done)
+	})
+	return call
+}
+
+// 设置一个加载函数，使Get在键不存在或已过期时自动调用loader（读穿透）：
+// loader返回true时以其返回的持续时间存储并返回该值，返回false时Get返回未找到且不存储任何内容
+// 同一个键的并发Get只会调用一次loader，其他调用者等待并共享结果。负缓存的键不会触发loader
+func WithLoader[T any](loader func(key string) (T, time.Duration, bool)) OptionPro[T] {
+	return func(c *cachePro[T]) {
+		c.loader = loader
+	}
+}
+
+// 限制同时运行的加载函数（WithLoader、GetOrLoad、GetOrComputeRange和GetStale的刷新）不超过n个，
+// n小于1时按1处理。超出的加载在队列中等待空闲的名额，其调用者一直阻塞到加载完成；
+// 同一个键的并发调用仍然共享同一次加载。加载使用独立的goroutine池，不占用SetMaxWorkers的名额，
+// 因此排队的加载不会阻塞其他后台任务
+func WithMaxConcurrentLoads[T any](n int) OptionPro[T] {
+	return func(c *cachePro[T]) {
+		c.loadWorkers = newWorkerPool(n)
+	}
+}
+
+// 使用WithLoader设置的loader加载键k，调用方不能持有锁
+func (c *cachePro[T]) loadMissing(k string) (T, bool) {
+	call := c.load(k, func() (T, time.Duration, error) {
+		v, d, ok := c.loader(k)
+		if !ok {
+			return v, 0, errLoaderMiss
+		}
+		return v, d, nil
+	})
+	<-call.done
+	if call.err != nil {
+		var zero T
+		return zero, false
+	}
+	return call.val, true
+}
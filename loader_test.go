@@ -0,0 +1,302 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestGetOrLoadDedup 测试并发调用只触发一次loader
+func TestGetOrLoadDedup(t *testing.T) {
+	tc := NewPro[int](DefaultExpiration, 0, nil)
+
+	var calls int32
+	loader := func(ctx context.Context) (int, error) {
+		atomic.AddInt32(&calls, 1)
+		time.Sleep(20 * time.Millisecond)
+		return 42, nil
+	}
+
+	n := 50
+	wg := new(sync.WaitGroup)
+	wg.Add(n)
+	for i := 0; i < n; i++ {
+		go func() {
+			defer wg.Done()
+			v, err := tc.GetOrLoad(context.Background(), "answer", loader, DefaultExpiration)
+			if err != nil {
+				t.Errorf("GetOrLoad failed: %v", err)
+			}
+			if v != 42 {
+				t.Errorf("Expected 42, got %v", v)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if calls != 1 {
+		t.Errorf("Expected loader to run once, ran %d times", calls)
+	}
+	v, found := tc.Get("answer")
+	if !found || v != 42 {
+		t.Errorf("Expected loaded value to be cached, got %v, %v", v, found)
+	}
+}
+
+// TestGetOrLoadCancel 测试取消的调用者不会影响其他调用者
+func TestGetOrLoadCancel(t *testing.T) {
+	tc := NewPro[string](DefaultExpiration, 0, nil)
+
+	release := make(chan struct{})
+	var calls int32
+	loader := func(ctx context.Context) (string, error) {
+		atomic.AddInt32(&calls, 1)
+		<-release
+		if ctx.Err() != nil {
+			return "", ctx.Err()
+		}
+		return "loaded", nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancelled := make(chan error)
+	go func() {
+		_, err := tc.GetOrLoad(ctx, "k", loader, DefaultExpiration)
+		cancelled <- err
+	}()
+
+	waiting := make(chan string)
+	go func() {
+		v, _ := tc.GetOrLoad(context.Background(), "k", loader, DefaultExpiration)
+		waiting <- v
+	}()
+
+	time.Sleep(10 * time.Millisecond)
+	cancel()
+	if err := <-cancelled; !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+
+	close(release)
+	if v := <-waiting; v != "loaded" {
+		t.Errorf("Expected 'loaded' for the remaining caller, got '%v'", v)
+	}
+	if calls != 1 {
+		t.Errorf("Expected loader to run once, ran %d times", calls)
+	}
+}
+
+// TestGetOrLoadError 测试loader出错时不存储结果
+func TestGetOrLoadError(t *testing.T) {
+	tc := NewPro[int](DefaultExpiration, 0, nil)
+
+	_, err := tc.GetOrLoad(context.Background(), "k", func(ctx context.Context) (int, error) {
+		return 0, errors.New("backend down")
+	}, DefaultExpiration)
+	if err == nil {
+		t.Error("Expected error from loader")
+	}
+	if _, found := tc.Get("k"); found {
+		t.Error("k was stored even though the loader failed")
+	}
+}
+
+// TestGetOrComputeRange 测试已存在的键直接返回，缺失的键只计算一次
+func TestGetOrComputeRange(t *testing.T) {
+	tc := NewPro[int](DefaultExpiration, 0, nil)
+	var keys []string
+	for i := 0; i < 10; i++ {
+		k := strconv.Itoa(i)
+		keys = append(keys, k)
+		if i%2 == 0 {
+			tc.Set(k, i, DefaultExpiration)
+		}
+	}
+
+	var mu sync.Mutex
+	computed := map[string]int{}
+	compute := func(k string) (int, error) {
+		mu.Lock()
+		computed[k]++
+		mu.Unlock()
+		time.Sleep(10 * time.Millisecond)
+		n, _ := strconv.Atoi(k)
+		return n * 10, nil
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			res, err := tc.GetOrComputeRange(keys, DefaultExpiration, compute)
+			if err != nil {
+				t.Errorf("GetOrComputeRange returned error: %v", err)
+			}
+			if len(res) != len(keys) {
+				t.Errorf("Expected %d results, got %d", len(keys), len(res))
+			}
+		}()
+	}
+	wg.Wait()
+
+	if len(computed) != 5 {
+		t.Errorf("Expected 5 keys to be computed, got %d", len(computed))
+	}
+	for k, n := range computed {
+		if i, _ := strconv.Atoi(k); i%2 == 0 {
+			t.Errorf("Preexisting key %s was computed", k)
+		}
+		if n != 1 {
+			t.Errorf("Expected %s to be computed once, got %d", k, n)
+		}
+	}
+	if v, _ := tc.Get("3"); v != 30 {
+		t.Errorf("Expected computed value 30 to be stored, got %d", v)
+	}
+	if v, _ := tc.Get("4"); v != 4 {
+		t.Errorf("Expected preexisting value 4, got %d", v)
+	}
+}
+
+// TestGetOrComputeRangeErrors 测试计算错误被合并返回且不存储
+func TestGetOrComputeRangeErrors(t *testing.T) {
+	tc := NewPro[int](DefaultExpiration, 0, nil)
+	errA := errors.New("a failed")
+	errB := errors.New("b failed")
+	res, err := tc.GetOrComputeRange([]string{"a", "b", "c"}, DefaultExpiration, func(k string) (int, error) {
+		switch k {
+		case "a":
+			return 0, errA
+		case "b":
+			return 0, errB
+		}
+		return 1, nil
+	})
+	if !errors.Is(err, errA) || !errors.Is(err, errB) {
+		t.Errorf("Expected joined errors, got %v", err)
+	}
+	if len(res) != 1 || res["c"] != 1 {
+		t.Errorf("Expected only c in result, got %v", res)
+	}
+	if _, found := tc.Get("a"); found {
+		t.Error("Failed compute result was stored")
+	}
+}
+
+// TestWithLoader 测试Get在未命中时自动加载，并发调用只加载一次
+func TestWithLoader(t *testing.T) {
+	var calls int32
+	loader := func(k string) (int, time.Duration, bool) {
+		atomic.AddInt32(&calls, 1)
+		time.Sleep(20 * time.Millisecond)
+		if k == "missing" {
+			return 0, 0, false
+		}
+		return len(k), DefaultExpiration, true
+	}
+	tc := NewPro[int](DefaultExpiration, 0, nil, WithLoader[int](loader))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if v, found := tc.Get("abc"); !found || v != 3 {
+				t.Errorf("Expected loaded value 3, got %d, %v", v, found)
+			}
+		}()
+	}
+	wg.Wait()
+	if n := atomic.LoadInt32(&calls); n != 1 {
+		t.Errorf("Expected loader to run once, ran %d times", n)
+	}
+	if tc.ItemCount() != 1 {
+		t.Errorf("Expected the loaded value to be stored, got %d items", tc.ItemCount())
+	}
+
+	if _, found := tc.Get("missing"); found {
+		t.Error("Expected a miss when the loader reports no value")
+	}
+	if tc.ItemCount() != 1 {
+		t.Error("Loader miss was stored")
+	}
+
+	tc.SetNegative("negative", DefaultExpiration)
+	before := atomic.LoadInt32(&calls)
+	if _, found := tc.Get("negative"); found {
+		t.Error("Expected a miss for a negative entry")
+	}
+	if atomic.LoadInt32(&calls) != before {
+		t.Error("Negative entry triggered the loader")
+	}
+}
+
+// TestMaxConcurrentLoads 测试大量不同键的加载同时运行的数量不超过上限，且同一个键仍然只加载一次
+func TestMaxConcurrentLoads(t *testing.T) {
+	const limit = 3
+	var running, peak, calls int32
+	loader := func(k string) (int, time.Duration, bool) {
+		n := atomic.AddInt32(&running, 1)
+		for {
+			p := atomic.LoadInt32(&peak)
+			if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+				break
+			}
+		}
+		atomic.AddInt32(&calls, 1)
+		time.Sleep(2 * time.Millisecond)
+		atomic.AddInt32(&running, -1)
+		return len(k), DefaultExpiration, true
+	}
+	tc := NewPro[int](DefaultExpiration, 0, nil, WithLoader[int](loader), WithMaxConcurrentLoads[int](limit))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 30; i++ {
+		for j := 0; j < 2; j++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				k := strconv.Itoa(i)
+				if v, found := tc.Get(k); !found || v != len(k) {
+					t.Errorf("Expected %d for %s, got %d, %v", len(k), k, v, found)
+				}
+			}()
+		}
+	}
+	wg.Wait()
+	if p := atomic.LoadInt32(&peak); p > limit {
+		t.Errorf("Expected at most %d concurrent loads, got %d", limit, p)
+	}
+	if n := atomic.LoadInt32(&calls); n != 30 {
+		t.Errorf("Expected one load per key, got %d", n)
+	}
+}
+
+// TestLoaderAfterClose 测试关闭后Get和GetOrLoad不再调用加载函数
+func TestLoaderAfterClose(t *testing.T) {
+	var calls atomic.Int32
+	tc := NewPro[int](DefaultExpiration, 0, nil, WithLoader(func(k string) (int, time.Duration, bool) {
+		calls.Add(1)
+		return 1, DefaultExpiration, true
+	}))
+	tc.Close()
+
+	if _, found := tc.Get("a"); found {
+		t.Error("Get found an item after Close")
+	}
+	_, err := tc.GetOrLoad(context.Background(), "b", func(ctx context.Context) (int, error) {
+		calls.Add(1)
+		return 1, nil
+	}, DefaultExpiration)
+	if !errors.Is(err, ErrClosed) {
+		t.Errorf("Expected ErrClosed, got %v", err)
+	}
+	if n := calls.Load(); n != 0 {
+		t.Errorf("Expected no loader calls after Close, got %d", n)
+	}
+}
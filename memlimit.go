@@ -0,0 +1,220 @@
+package cache
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// 按最近使用顺序排列的键，默认的驱逐策略。Get在只持有读锁时也会更新它，因此使用自己的互斥锁
+type lruList struct {
+	mu    sync.Mutex
+	ll    *list.List
+	elems map[string]*list.Element
+}
+
+// NewLRUPolicy 返回按最近最少使用（LRU）顺序驱逐的Policy，这是NewProWithMemLimit的默认策略
+func NewLRUPolicy() Policy {
+	return newLRUList()
+}
+
+func newLRUList() *lruList {
+	return &lruList{
+		ll:    list.New(),
+		elems: make(map[string]*list.Element),
+	}
+}
+
+func (l *lruList) RecordAccess(k string) {
+	l.RecordInsert(k)
+}
+
+// 将键标记为最近使用
+func (l *lruList) RecordInsert(k string) {
+	l.mu.Lock()
+	if e, ok := l.elems[k]; ok {
+		l.ll.MoveToFront(e)
+	} else {
+		l.elems[k] = l.ll.PushFront(k)
+	}
+	l.mu.Unlock()
+}
+
+func (l *lruList) RecordRemove(k string) {
+	l.mu.Lock()
+	if e, ok := l.elems[k]; ok {
+		l.ll.Remove(e)
+		delete(l.elems, k)
+	}
+	l.mu.Unlock()
+}
+
+// 移除并返回最久未使用的键
+func (l *lruList) Evict() (string, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	e := l.ll.Back()
+	if e == nil {
+		return "", false
+	}
+	k := l.ll.Remove(e).(string)
+	delete(l.elems, k)
+	return k, true
+}
+
+// 返回一个按估算内存占用限制大小的新CachePro。sizeOf用于估算每个值占用的字节数，
+// 每次Set、Add、Replace或SetIfExpired写入后，如果所有值的总大小超过maxBytes，
+// 则按驱逐策略选出的顺序驱逐项目（调用delFunc和onEvicted），直到总大小不超过maxBytes
+// 默认按最近最少使用（LRU）的顺序驱逐，可以用WithPolicy指定其他策略，例如NewLFUPolicy
+// 使用默认策略时，使用SetWithPriority写入的项目会按优先级从低到高驱逐，优先级相同时再按LRU顺序
+//
+// 刚写入的项目本身不会被这次写入驱逐：如果单个项目就超过maxBytes，它会被保存下来
+// （其他项目全部被驱逐），并在下一次写入时作为最久未使用的项目被驱逐
+// 其他写入方法（例如Compute）不会立即触发驱逐，超出的部分会在下一次上述写入时处理
+func NewProWithMemLimit[T any](defaultExpiration, cleanupInterval time.Duration, maxBytes int64, sizeOf func(T) int64, DelFunc func(T), opts ...OptionPro[T]) *CachePro[T] {
+	c := NewPro[T](defaultExpiration, cleanupInterval, DelFunc, opts...)
+	c.maxBytes = maxBytes
+	c.sizeOf = sizeOf
+	if c.policy == nil {
+		c.policy = newLRUList()
+	}
+	return c
+}
+
+// SetWithPriority 与Set相同，但为项目指定一个优先级（默认为0）
+// 超出NewProWithMemLimit的上限时，优先级较低的项目总是先于优先级较高的项目被驱逐
+// 之后用Set等方法覆盖该项目会将优先级重置为0
+func (c *CachePro[T]) SetWithPriority(k string, x T, d time.Duration, priority int) {
+	c.mustBeOpen()
+	c.mu.Lock()
+	c.overwrite(k, ItemPro[T]{
+		Object:     x,
+		Expiration: c.expiration(d),
+		Priority:   priority,
+	})
+	c.unlockAndEvict(k)
+}
+
+// 返回CachePro中所有值的估算总字节数。只有使用NewProWithMemLimit创建的CachePro才会统计
+func (c *CachePro[T]) MemoryBytes() int64 {
+	c.mu.RLock()
+	n := c.curBytes
+	c.mu.RUnlock()
+	return n
+}
+
+// 更新键k被写入item后的内存统计，调用方必须持有写锁
+func (c *cachePro[T]) trackPut(k string, item ItemPro[T]) {
+	if c.sizeOf != nil {
+		if old, found := c.items[k]; found {
+			c.curBytes -= c.sizeOf(old.Object)
+			if old.Priority != 0 {
+				c.prioritized--
+			}
+		}
+		c.curBytes += c.sizeOf(item.Object)
+		if item.Priority != 0 {
+			c.prioritized++
+		}
+	}
+	c.policy.RecordInsert(k)
+}
+
+// 更新键k被移除后的内存统计，调用方必须持有写锁
+func (c *cachePro[T]) trackRemove(k string) {
+	if old, found := c.items[k]; found && c.sizeOf != nil {
+		c.curBytes -= c.sizeOf(old.Object)
+		if old.Priority != 0 {
+			c.prioritized--
+		}
+	}
+	c.policy.RecordRemove(k)
+}
+
+// 在全部项目从old替换为c.items后重新统计，调用方必须持有写锁
+func (c *cachePro[T]) trackReset(old map[string]ItemPro[T]) {
+	c.curBytes = 0
+	c.prioritized = 0
+	for k := range old {
+		c.policy.RecordRemove(k)
+	}
+	for k, v := range c.items {
+		if c.sizeOf != nil {
+			c.curBytes += c.sizeOf(v.Object)
+			if v.Priority != 0 {
+				c.prioritized++
+			}
+		}
+		c.policy.RecordInsert(k)
+	}
+}
+
+// 返回下一个应被驱逐的键：使用默认策略且有带优先级的项目时，为优先级最低的项目中最久未使用的一个（不包括kept的键），
+// 否则由驱逐策略选出（可能是kept的键）。调用方必须持有写锁
+func (c *cachePro[T]) evictionCandidate(kept func(k string) bool) (string, bool) {
+	l, ok := c.policy.(*lruList)
+	if !ok || c.prioritized == 0 {
+		return c.policy.Evict()
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	var victim string
+	var minPriority int
+	found := false
+	for e := l.ll.Back(); e != nil; e = e.Prev() {
+		k := e.Value.(string)
+		if kept(k) {
+			continue
+		}
+		if p := c.items[k].Priority; !found || p < minPriority {
+			victim, minPriority, found = k, p, true
+		}
+	}
+	return victim, found
+}
+
+// 在持有写锁时按驱逐策略驱逐项目直到总大小不超过上限（但不驱逐keep中的键，通常是刚写入的键），
+// 然后释放写锁并调用onEvicted
+func (c *cachePro[T]) unlockAndEvict(keep ...string) {
+	var evictedItems []keyAndValuePro
+	var skipped []string
+	var keepSet map[string]struct{}
+	kept := func(k string) bool {
+		if len(keep) <= 1 {
+			return len(keep) == 1 && k == keep[0]
+		}
+		if keepSet == nil {
+			keepSet = make(map[string]struct{}, len(keep))
+			for _, k := range keep {
+				keepSet[k] = struct{}{}
+			}
+		}
+		_, ok := keepSet[k]
+		return ok
+	}
+	for c.maxBytes > 0 && c.curBytes > c.maxBytes {
+		k, ok := c.evictionCandidate(kept)
+		if !ok {
+			break
+		}
+		if kept(k) {
+			// 策略已不再跟踪该键，驱逐结束后重新记录
+			skipped = append(skipped, k)
+			continue
+		}
+		if _, found := c.items[k]; !found {
+			continue
+		}
+		v, evicted := c.delete(k)
+		if evicted {
+			evictedItems = append(evictedItems, keyAndValuePro{k, v})
+		}
+	}
+	for _, k := range skipped {
+		c.policy.RecordInsert(k)
+	}
+	c.mu.Unlock()
+	for _, v := range evictedItems {
+		c.notifyEvicted(v.key, v.value)
+	}
+}
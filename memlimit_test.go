@@ -0,0 +1,157 @@
+package cache
+
+import (
+	"strconv"
+	"testing"
+)
+
+// TestMemLimitEvictsLRU 测试超过内存上限时按LRU顺序驱逐
+func TestMemLimitEvictsLRU(t *testing.T) {
+	var destroyed []string
+	tc := NewProWithMemLimit[[]byte](DefaultExpiration, 0, 100, func(b []byte) int64 {
+		return int64(len(b))
+	}, func(b []byte) {
+		destroyed = append(destroyed, string(b[:1]))
+	})
+
+	tc.Set("a", []byte("a"+string(make([]byte, 39))), DefaultExpiration)
+	tc.Set("b", []byte("b"+string(make([]byte, 39))), DefaultExpiration)
+	if tc.MemoryBytes() != 80 {
+		t.Errorf("Expected 80 bytes, got %d", tc.MemoryBytes())
+	}
+
+	// 访问a，使b成为最久未使用的项目
+	tc.Get("a")
+	tc.Set("c", []byte("c"+string(make([]byte, 39))), DefaultExpiration)
+
+	if _, found := tc.Get("b"); found {
+		t.Error("b should have been evicted")
+	}
+	if _, found := tc.Get("a"); !found {
+		t.Error("a should have survived eviction")
+	}
+	if tc.MemoryBytes() != 80 {
+		t.Errorf("Expected 80 bytes after eviction, got %d", tc.MemoryBytes())
+	}
+	if len(destroyed) != 1 || destroyed[0] != "b" {
+		t.Errorf("Expected delFunc to run for b, got %v", destroyed)
+	}
+
+	tc.Delete("a")
+	if tc.MemoryBytes() != 40 {
+		t.Errorf("Expected 40 bytes after Delete, got %d", tc.MemoryBytes())
+	}
+}
+
+// TestMemLimitOversizedItem 测试单个项目超过内存上限
+func TestMemLimitOversizedItem(t *testing.T) {
+	tc := NewProWithMemLimit[[]byte](DefaultExpiration, 0, 100, func(b []byte) int64 {
+		return int64(len(b))
+	}, nil)
+
+	tc.Set("small", make([]byte, 10), DefaultExpiration)
+	tc.Set("huge", make([]byte, 200), DefaultExpiration)
+	if _, found := tc.Get("huge"); !found {
+		t.Error("Oversized item should be stored")
+	}
+	if _, found := tc.Get("small"); found {
+		t.Error("small should have been evicted to make room")
+	}
+
+	tc.Set("next", make([]byte, 10), DefaultExpiration)
+	if _, found := tc.Get("huge"); found {
+		t.Error("Oversized item should be evicted by the next write")
+	}
+	if tc.MemoryBytes() != 10 {
+		t.Errorf("Expected 10 bytes, got %d", tc.MemoryBytes())
+	}
+
+	tc.Flush()
+	if tc.MemoryBytes() != 0 {
+		t.Errorf("Expected 0 bytes after Flush, got %d", tc.MemoryBytes())
+	}
+}
+
+// TestMemLimitPriority 测试驱逐时优先选择低优先级的项目，优先级相同时按LRU顺序
+func TestMemLimitPriority(t *testing.T) {
+	tc := NewProWithMemLimit[int](DefaultExpiration, 0, 3, func(int) int64 { return 1 }, nil)
+	tc.SetWithPriority("session", 1, DefaultExpiration, 10)
+	tc.SetWithPriority("fragment1", 2, DefaultExpiration, 0)
+	tc.SetWithPriority("fragment2", 3, DefaultExpiration, 0)
+
+	// session是最久未使用的，但优先级最高
+	tc.Set("fragment3", 4, DefaultExpiration)
+	if _, found := tc.Get("session"); !found {
+		t.Error("High-priority session was evicted")
+	}
+	if _, found := tc.Get("fragment1"); found {
+		t.Error("Expected the least recently used low-priority item to be evicted")
+	}
+
+	tc.Set("fragment4", 5, DefaultExpiration)
+	if _, found := tc.Get("fragment2"); found {
+		t.Error("Expected fragment2 to be evicted next")
+	}
+	if _, found := tc.Get("session"); !found {
+		t.Error("High-priority session was evicted")
+	}
+	if tc.ItemCount() != 3 {
+		t.Errorf("Expected 3 items, got %d", tc.ItemCount())
+	}
+}
+
+// TestMemLimitBatchKeepsAllKeys 测试批量写入触发的驱逐不会驱逐同一批中先写入的项目，
+// 即使这一批本身就超过了上限（与单个超大项目一样，超出的部分在下一次写入时处理）
+func TestMemLimitBatchKeepsAllKeys(t *testing.T) {
+	sizeOf := func(int) int64 { return 1 }
+	tc := NewProWithMemLimit[int](DefaultExpiration, 0, 3, sizeOf, nil)
+	tc.Set("old1", 0, DefaultExpiration)
+	tc.Set("old2", 0, DefaultExpiration)
+	tc.SetManyWithExpiration(map[string]EntryPro[int]{
+		"a": {Value: 1},
+		"b": {Value: 2},
+		"c": {Value: 3},
+		"d": {Value: 4},
+	})
+	items := tc.Items()
+	for _, k := range []string{"a", "b", "c", "d"} {
+		if _, found := items[k]; !found {
+			t.Errorf("SetManyWithExpiration evicted %s from its own batch", k)
+		}
+	}
+	if len(items) != 4 {
+		t.Errorf("Expected only the batch to remain, got %d items", len(items))
+	}
+
+	SetAll(tc, []int{5, 6, 7, 8}, func(v int) string { return strconv.Itoa(v) }, DefaultExpiration)
+	items = tc.Items()
+	for _, k := range []string{"5", "6", "7", "8"} {
+		if _, found := items[k]; !found {
+			t.Errorf("SetAll evicted %s from its own batch", k)
+		}
+	}
+	if len(items) != 4 || tc.MemoryBytes() != 4 {
+		t.Errorf("Expected only the batch to remain, got %d items and %d bytes", len(items), tc.MemoryBytes())
+	}
+}
+
+// TestMemLimitMerge 测试合并之后立即执行内存上限
+func TestMemLimitMerge(t *testing.T) {
+	sizeOf := func(int) int64 { return 1 }
+	tc := NewProWithMemLimit[int](DefaultExpiration, 0, 3, sizeOf, nil)
+	tc.Set("mine1", 1, DefaultExpiration)
+	tc.Set("mine2", 2, DefaultExpiration)
+
+	other := NewPro[int](DefaultExpiration, 0, nil)
+	for i := 0; i < 5; i++ {
+		other.Set(strconv.Itoa(i), i, DefaultExpiration)
+	}
+	tc.Merge(other, KeepTheirs)
+
+	if tc.MemoryBytes() > 3 || tc.ItemCount() > 3 {
+		t.Errorf("Expected Merge to enforce the limit, got %d items and %d bytes", tc.ItemCount(), tc.MemoryBytes())
+	}
+	if other.ItemCount() != 5 {
+		t.Errorf("Merge modified the other cache, got %d items", other.ItemCount())
+	}
+}
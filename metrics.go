@@ -0,0 +1,40 @@
+package cache
+
+import (
+	"expvar"
+)
+
+// CachePro的运行统计
+type Metrics struct {
+	// Get、GetWithExpiration、Has和GetEntry命中的次数（GetEntry读到负缓存项目也算命中）
+	Hits uint64
+	// 上述读取未命中（不存在或已过期）的次数
+	Misses uint64
+	// 项目被删除或驱逐的次数（包括手动删除、过期清理和因内存上限被驱逐）
+	Evictions uint64
+	// 当前的项目数，可能包括已过期但尚未清理的项目
+	Items int
+}
+
+// 返回CachePro当前的运行统计
+func (c *CachePro[T]) Metrics() Metrics {
+	return Metrics{
+		Hits:      c.hits.Load(),
+		Misses:    c.misses.Load(),
+		Evictions: c.evictions.Load(),
+		Items:     c.ItemCount(),
+	}
+}
+
+// 以给定的名称将CachePro的运行统计发布到expvar（例如/debug/vars），每次读取时实时计算
+// 需要Prometheus指标时请使用独立的prometheus子模块中的Collector
+//
+// 与expvar.Publish一样，重复使用同一个名称会导致panic。已发布的变量无法撤销，
+// 因此会一直引用CachePro的底层数据
+func (c *CachePro[T]) RegisterExpvar(name string) {
+	// 只引用内部的cachePro，以免阻止外层的CachePro被回收（参见newCacheProWithJanitor）
+	cp := c.cachePro
+	expvar.Publish(name, expvar.Func(func() any {
+		return (&CachePro[T]{cp}).Metrics()
+	}))
+}
@@ -0,0 +1,70 @@
+package cache
+
+import (
+	"encoding/json"
+	"expvar"
+	"strconv"
+	"sync/atomic"
+	"testing"
+)
+
+// expvar不允许重复发布同一个名字，每次运行TestMetricsExpvar（例如-count=2）使用不同的名字
+var expvarRuns atomic.Int64
+
+// TestMetricsExpvar 测试发布到expvar的统计随操作变化
+func TestMetricsExpvar(t *testing.T) {
+	tc := NewPro[int](DefaultExpiration, 0, nil)
+	name := "TestMetricsExpvar" + strconv.FormatInt(expvarRuns.Add(1), 10)
+	tc.RegisterExpvar(name)
+
+	scrape := func() Metrics {
+		var m Metrics
+		v := expvar.Get(name)
+		if v == nil {
+			t.Fatalf("%s was not published", name)
+		}
+		if err := json.Unmarshal([]byte(v.String()), &m); err != nil {
+			t.Fatalf("Failed to parse published metrics: %v", err)
+		}
+		return m
+	}
+
+	if m := scrape(); m != (Metrics{}) {
+		t.Errorf("Expected zero metrics, got %+v", m)
+	}
+
+	tc.Set("a", 1, DefaultExpiration)
+	tc.Set("b", 2, DefaultExpiration)
+	tc.Get("a")
+	tc.Get("a")
+	tc.Get("missing")
+	tc.Delete("b")
+
+	want := Metrics{Hits: 2, Misses: 1, Evictions: 1, Items: 1}
+	if m := scrape(); m != want {
+		t.Errorf("Expected %+v, got %+v", want, m)
+	}
+	if m := tc.Metrics(); m != want {
+		t.Errorf("Expected Metrics() to match expvar, got %+v", m)
+	}
+}
+
+// TestMetricsAllReaders 测试GetWithExpiration、Has和GetEntry也会更新命中和未命中次数
+func TestMetricsAllReaders(t *testing.T) {
+	tc := NewPro[int](DefaultExpiration, 0, nil)
+	tc.Set("a", 1, DefaultExpiration)
+	tc.SetNegative("neg", DefaultExpiration)
+
+	tc.GetWithExpiration("a")
+	tc.GetWithExpiration("missing")
+	tc.Has("a")
+	tc.Has("neg")
+	tc.GetEntry("a")
+	tc.GetEntry("neg")
+	tc.GetEntry("missing")
+
+	m := tc.Metrics()
+	if m.Hits != 4 || m.Misses != 3 {
+		t.Errorf("Expected 4 hits and 3 misses, got %d and %d", m.Hits, m.Misses)
+	}
+}
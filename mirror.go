@@ -0,0 +1,82 @@
+package cache
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Mirror的写入目标。*CachePro[T]本身就实现了这个接口，因此可以直接作为热备缓存
+type MirrorSink[T any] interface {
+	Set(k string, x T, d time.Duration)
+	Delete(k string)
+}
+
+// 等待写入镜像目标的操作数上限，超出的操作会被丢弃并计数
+const mirrorBufferSize = 1024
+
+type mirrorPro[T any] struct {
+	sink    MirrorSink[T]
+	queue   *serialQueue
+	dropped atomic.Uint64
+}
+
+// 将一次写入转发给镜像目标，调用方必须持有写锁
+// 剩余的过期时间按写入时CachePro的时钟（now，UnixNano）计算，而不是转发时的真实时间
+// 负缓存项目表示键不存在，转发为删除
+func (m *mirrorPro[T]) set(k string, item ItemPro[T], now int64) {
+	if item.Negative {
+		m.delete(k)
+		return
+	}
+	d := NoExpiration
+	if item.Expiration > 0 {
+		d = time.Duration(item.Expiration - now)
+		if d <= 0 {
+			m.delete(k)
+			return
+		}
+	}
+	if !m.queue.push(func() {
+		m.sink.Set(k, item.Object, d)
+	}) {
+		m.dropped.Add(1)
+	}
+}
+
+// 将一次删除转发给镜像目标，调用方必须持有写锁
+func (m *mirrorPro[T]) delete(k string) {
+	if !m.queue.push(func() {
+		m.sink.Delete(k)
+	}) {
+		m.dropped.Add(1)
+	}
+}
+
+// 设置一个（可选的）镜像目标，此后对CachePro的每次写入和删除都会按顺序异步地转发给sink，
+// 不会阻塞CachePro本身的操作。转发在后台goroutine池中执行，等待转发的操作最多缓冲
+// mirrorBufferSize个，超出的操作会被丢弃，可以通过DroppedMirrorWrites查看丢弃的数量
+// Flush不会被转发
+// 设置为nil以禁用
+func (c *CachePro[T]) Mirror(sink MirrorSink[T]) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if sink == nil {
+		c.mirror = nil
+		return
+	}
+	c.mirror = &mirrorPro[T]{
+		sink:  sink,
+		queue: newSerialQueue(c.workers, mirrorBufferSize),
+	}
+}
+
+// 返回因缓冲区已满而未能转发给镜像目标的操作数
+func (c *CachePro[T]) DroppedMirrorWrites() uint64 {
+	c.mu.RLock()
+	m := c.mirror
+	c.mu.RUnlock()
+	if m == nil {
+		return 0
+	}
+	return m.dropped.Load()
+}
@@ -0,0 +1,128 @@
+package cache
+
+import (
+	"strconv"
+	"testing"
+	"time"
+)
+
+// TestMirrorConverges 测试热备缓存最终与主缓存一致
+func TestMirrorConverges(t *testing.T) {
+	primary := NewPro[int](DefaultExpiration, 0, nil)
+	standby := NewPro[int](DefaultExpiration, 0, nil)
+	primary.Mirror(standby)
+
+	for i := 0; i < 100; i++ {
+		primary.Set(strconv.Itoa(i), i, DefaultExpiration)
+	}
+	for i := 0; i < 100; i += 3 {
+		primary.Delete(strconv.Itoa(i))
+	}
+	primary.Set("ttl", 1, time.Hour)
+	primary.Replace("1", 1000, DefaultExpiration)
+
+	primary.mirror.queue.wait()
+
+	want := primary.Items()
+	got := standby.Items()
+	if len(got) != len(want) {
+		t.Fatalf("Expected %d items in standby, got %d", len(want), len(got))
+	}
+	for k, v := range want {
+		if got[k].Object != v.Object {
+			t.Errorf("Expected %s to be %v in standby, got %v", k, v.Object, got[k].Object)
+		}
+	}
+	if _, exp, _ := standby.GetWithExpiration("ttl"); time.Until(exp) <= 59*time.Minute {
+		t.Errorf("Expected ttl to keep about 1h remaining in standby, got %v", time.Until(exp))
+	}
+	if primary.DroppedMirrorWrites() != 0 {
+		t.Errorf("Expected no dropped writes, got %d", primary.DroppedMirrorWrites())
+	}
+}
+
+// 记录转发的过期时间的镜像目标
+type ttlSink struct {
+	ttls map[string]time.Duration
+}
+
+func (s *ttlSink) Set(k string, x int, d time.Duration) {
+	s.ttls[k] = d
+}
+
+func (s *ttlSink) Delete(k string) {
+	delete(s.ttls, k)
+}
+
+// TestMirrorUsesCacheClock 测试转发的剩余过期时间按主缓存的时钟计算
+func TestMirrorUsesCacheClock(t *testing.T) {
+	clk := newFakeClock()
+	primary := NewPro[int](DefaultExpiration, 0, nil, withClock[int](clk))
+	sink := &ttlSink{ttls: map[string]time.Duration{}}
+	primary.Mirror(sink)
+
+	primary.Set("hour", 1, time.Hour)
+	primary.Set("forever", 2, NoExpiration)
+	primary.Set("expired", 3, time.Hour)
+	clk.Advance(2 * time.Second)
+	primary.SetExpiration("expired", clk.Now().Add(-time.Second))
+	primary.mirror.queue.wait()
+
+	if d := sink.ttls["hour"]; d != time.Hour {
+		t.Errorf("Expected a TTL of 1h, got %v", d)
+	}
+	if d := sink.ttls["forever"]; d != NoExpiration {
+		t.Errorf("Expected NoExpiration, got %v", d)
+	}
+	if _, found := sink.ttls["expired"]; found {
+		t.Error("An already expired write was forwarded as a Set")
+	}
+}
+
+// TestMirrorNegativeEntry 测试负缓存项目被转发为删除，而不是以零值写入
+func TestMirrorNegativeEntry(t *testing.T) {
+	primary := NewPro[int](DefaultExpiration, 0, nil)
+	sink := &ttlSink{ttls: map[string]time.Duration{}}
+	primary.Mirror(sink)
+
+	primary.Set("a", 1, time.Hour)
+	primary.SetNegative("a", time.Minute)
+	primary.SetNegative("b", time.Minute)
+	primary.mirror.queue.wait()
+
+	if len(sink.ttls) != 0 {
+		t.Errorf("Expected negative entries to be forwarded as deletes, got %v", sink.ttls)
+	}
+}
+
+// 阻塞直到被释放的镜像目标
+type blockingSink struct {
+	release chan struct{}
+}
+
+func (s *blockingSink) Set(k string, x int, d time.Duration) {
+	<-s.release
+}
+
+func (s *blockingSink) Delete(k string) {
+	<-s.release
+}
+
+// TestMirrorDropsOnOverflow 测试缓冲区已满时丢弃并计数
+func TestMirrorDropsOnOverflow(t *testing.T) {
+	primary := NewPro[int](DefaultExpiration, 0, nil)
+	sink := &blockingSink{release: make(chan struct{})}
+	primary.Mirror(sink)
+
+	n := mirrorBufferSize + 100
+	for i := 0; i < n; i++ {
+		primary.Set(strconv.Itoa(i), i, DefaultExpiration)
+	}
+	if primary.ItemCount() != n {
+		t.Errorf("Expected primary writes not to be blocked, got %d items", primary.ItemCount())
+	}
+	if primary.DroppedMirrorWrites() == 0 {
+		t.Error("Expected some mirror writes to be dropped")
+	}
+	close(sink.release)
+}
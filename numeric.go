@@ -0,0 +1,162 @@
+package cache
+
+import (
+	"fmt"
+	"time"
+)
+
+// Integer 是所有整数类型的约束
+type Integer interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64 |
+		~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 | ~uintptr
+}
+
+// Float 是所有浮点数类型的约束
+type Float interface {
+	~float32 | ~float64
+}
+
+// Number 是所有可以进行加减运算的数值类型的约束
+type Number interface {
+	Integer | Float
+}
+
+// 如果T是浮点数类型则返回true
+func isFloat[T Number]() bool {
+	var half T = 1
+	half /= 2
+	return half != 0
+}
+
+// 在同一个写锁内对键k的值应用f并保存结果，保持项目原有的过期时间
+func updateNumber[T Number](c *CachePro[T], k string, f func(v T) (T, error)) (T, error) {
+	c.mustBeOpen()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	item, found := c.items[k]
+	if !found || item.Negative || c.expired(item) {
+		return 0, fmt.Errorf("Item %s not found", k)
+	}
+	nv, err := f(item.Object)
+	if err != nil {
+		return 0, err
+	}
+	c.put(k, ItemPro[T]{
+		Object:     nv,
+		Expiration: item.Expiration,
+	})
+	return nv, nil
+}
+
+// Increment 将项目增加n并返回增加后的值。如果项目未找到，或者对整数类型来说结果会溢出，则返回错误（值保持不变）
+// 浮点数类型不做溢出检查
+func Increment[T Number](c *CachePro[T], k string, n T) (T, error) {
+	return updateNumber(c, k, func(v T) (T, error) {
+		return add(k, v, n)
+	})
+}
+
+// 返回v+n，对整数类型来说结果会溢出时返回错误
+func add[T Number](k string, v, n T) (T, error) {
+	r := v + n
+	if !isFloat[T]() && ((n > 0 && r < v) || (n < 0 && r > v)) {
+		return 0, fmt.Errorf("Incrementing %s by %v overflows", k, n)
+	}
+	return r, nil
+}
+
+// IncrementNewTTL 在同一个写锁内增加计数器：键不存在或已过期时以值n和过期时间d创建它，
+// 否则将其增加n并保持原有的过期时间（与Redis的INCR加EXPIRE NX相同），返回增加后的值
+// 适用于固定窗口限流：窗口从第一次计数开始，之后的计数不会延长窗口。整数溢出时返回错误（值保持不变）
+func IncrementNewTTL[T Number](c *CachePro[T], k string, n T, d time.Duration) (T, error) {
+	c.mustBeOpen()
+	c.mu.Lock()
+	item, found := c.items[k]
+	if !found || item.Negative || c.expired(item) {
+		c.overwrite(k, ItemPro[T]{
+			Object:     n,
+			Expiration: c.expiration(d),
+		})
+		c.unlockAndEvict(k)
+		return n, nil
+	}
+	defer c.mu.Unlock()
+	r, err := add(k, item.Object, n)
+	if err != nil {
+		return 0, err
+	}
+	c.put(k, ItemPro[T]{
+		Object:     r,
+		Expiration: item.Expiration,
+	})
+	return r, nil
+}
+
+// IncrementExisting 将已存在的项目增加n并返回增加后的值。如果键不存在或已过期，则返回错误，
+// 而不会像Compute那样以默认值创建该项目，适用于必须先显式初始化的计数器（例如限流）
+// 目前与Increment的行为相同，但保证今后也不会创建键
+func IncrementExisting[T Number](c *CachePro[T], k string, n T) (T, error) {
+	return Increment(c, k, n)
+}
+
+// Decrement 将项目减少n并返回减少后的值。如果项目未找到，或者对整数类型来说结果会溢出
+// （包括无符号整数减到0以下），则返回错误（值保持不变）。浮点数类型不做溢出检查
+func Decrement[T Number](c *CachePro[T], k string, n T) (T, error) {
+	return updateNumber(c, k, func(v T) (T, error) {
+		r := v - n
+		if !isFloat[T]() && ((n > 0 && r > v) || (n < 0 && r < v)) {
+			return 0, fmt.Errorf("Decrementing %s by %v overflows", k, n)
+		}
+		return r, nil
+	})
+}
+
+// IncrementFloat 将浮点数项目增加n并返回增加后的值。如果项目未找到，则返回错误
+func IncrementFloat[T Float](c *CachePro[T], k string, n T) (T, error) {
+	return updateNumber(c, k, func(v T) (T, error) {
+		return v + n, nil
+	})
+}
+
+// DecrementFloat 将浮点数项目减少n并返回减少后的值。如果项目未找到，则返回错误
+func DecrementFloat[T Float](c *CachePro[T], k string, n T) (T, error) {
+	return updateNumber(c, k, func(v T) (T, error) {
+		return v - n, nil
+	})
+}
+
+// 在同一个读锁内用f依次合并所有未过期项目的值。没有未过期的项目时返回零值和false
+func aggregate[T Number](c *CachePro[T], f func(acc, v T) T) (T, bool) {
+	var acc T
+	found := false
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	now := c.clock.Now().UnixNano()
+	for _, item := range c.items {
+		if item.Negative || (item.Expiration > 0 && now > item.Expiration) {
+			continue
+		}
+		if !found {
+			acc, found = item.Object, true
+			continue
+		}
+		acc = f(acc, item.Object)
+	}
+	return acc, found
+}
+
+// Sum 返回所有未过期项目的值之和，以及CachePro中是否有未过期的项目
+// 整数类型溢出时按Go的规则回绕，不返回错误
+func Sum[T Number](c *CachePro[T]) (T, bool) {
+	return aggregate(c, func(acc, v T) T { return acc + v })
+}
+
+// Max 返回所有未过期项目中的最大值，以及CachePro中是否有未过期的项目
+func Max[T Number](c *CachePro[T]) (T, bool) {
+	return aggregate(c, func(acc, v T) T { return max(acc, v) })
+}
+
+// Min 返回所有未过期项目中的最小值，以及CachePro中是否有未过期的项目
+func Min[T Number](c *CachePro[T]) (T, bool) {
+	return aggregate(c, func(acc, v T) T { return min(acc, v) })
+}
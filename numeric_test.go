@@ -0,0 +1,187 @@
+package cache
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+// TestIncrementOverflow 测试整数增加和减少时溢出返回错误且值保持不变
+func TestIncrementOverflow(t *testing.T) {
+	tc := NewPro[int64](DefaultExpiration, 0, nil)
+	tc.Set("n", math.MaxInt64-1, DefaultExpiration)
+
+	v, err := Increment(tc, "n", 1)
+	if err != nil || v != math.MaxInt64 {
+		t.Fatalf("Expected MaxInt64, got %d, %v", v, err)
+	}
+	if _, err := Increment(tc, "n", 1); err == nil {
+		t.Error("Expected overflow error")
+	}
+	if v, _ := tc.Get("n"); v != math.MaxInt64 {
+		t.Errorf("Expected value to be unchanged after overflow, got %d", v)
+	}
+
+	tc.Set("min", math.MinInt64, DefaultExpiration)
+	if _, err := Decrement(tc, "min", 1); err == nil {
+		t.Error("Expected underflow error")
+	}
+	if _, err := Increment(tc, "min", -1); err == nil {
+		t.Error("Expected underflow error for negative increment")
+	}
+	if _, err := Increment(tc, "missing", 1); err == nil {
+		t.Error("Expected error for missing key")
+	}
+
+	uc := NewPro[uint8](DefaultExpiration, 0, nil)
+	uc.Set("u", 1, DefaultExpiration)
+	if v, err := Decrement(uc, "u", 1); err != nil || v != 0 {
+		t.Errorf("Expected 0, got %d, %v", v, err)
+	}
+	if _, err := Decrement(uc, "u", 1); err == nil {
+		t.Error("Expected error when decrementing uint8 below zero")
+	}
+	uc.Set("u", 255, DefaultExpiration)
+	if _, err := Increment(uc, "u", 1); err == nil {
+		t.Error("Expected uint8 overflow error")
+	}
+}
+
+// TestIncrementFloat 测试浮点数的增加和减少
+func TestIncrementFloat(t *testing.T) {
+	tc := NewPro[float64](DefaultExpiration, 0, nil)
+	tc.Set("f", 1.5, DefaultExpiration)
+
+	if v, err := IncrementFloat(tc, "f", 2.25); err != nil || v != 3.75 {
+		t.Errorf("Expected 3.75, got %v, %v", v, err)
+	}
+	if v, err := DecrementFloat(tc, "f", 0.75); err != nil || v != 3 {
+		t.Errorf("Expected 3, got %v, %v", v, err)
+	}
+	if v, err := Increment(tc, "f", 0.5); err != nil || v != 3.5 {
+		t.Errorf("Expected 3.5, got %v, %v", v, err)
+	}
+	if _, err := IncrementFloat(tc, "missing", 1); err == nil {
+		t.Error("Expected error for missing key")
+	}
+
+	fc := NewPro[float32](DefaultExpiration, 0, nil)
+	fc.Set("f", math.MaxFloat32, DefaultExpiration)
+	if _, err := Increment(fc, "f", math.MaxFloat32); err != nil {
+		t.Errorf("Expected float increment not to be overflow-checked, got %v", err)
+	}
+}
+
+// TestIncrementExisting 测试键不存在或已过期时返回错误且不创建键
+func TestIncrementExisting(t *testing.T) {
+	clk := newFakeClock()
+	tc := NewPro[int](DefaultExpiration, 0, nil, withClock[int](clk))
+
+	if _, err := IncrementExisting(tc, "missing", 1); err == nil {
+		t.Error("Expected error for missing key")
+	}
+	if _, found := tc.Get("missing"); found {
+		t.Error("IncrementExisting created a missing key")
+	}
+
+	tc.Set("expired", 5, time.Second)
+	clk.Advance(2 * time.Second)
+	if _, err := IncrementExisting(tc, "expired", 1); err == nil {
+		t.Error("Expected error for expired key")
+	}
+
+	tc.Set("n", 5, DefaultExpiration)
+	if v, err := IncrementExisting(tc, "n", 2); err != nil || v != 7 {
+		t.Errorf("Expected 7, got %d, %v", v, err)
+	}
+}
+
+// TestIncrementNewTTL 测试第一次计数时设置过期时间，之后的计数不会重置它
+func TestIncrementNewTTL(t *testing.T) {
+	clk := newFakeClock()
+	tc := NewPro[int](DefaultExpiration, 0, nil, withClock[int](clk))
+
+	if v, err := IncrementNewTTL(tc, "hits", 1, time.Minute); err != nil || v != 1 {
+		t.Fatalf("Expected 1, got %d, %v", v, err)
+	}
+	_, want, _ := tc.GetWithExpiration("hits")
+	if !want.Equal(clk.Now().Add(time.Minute)) {
+		t.Errorf("Expected the TTL to be set on creation, got %v", want)
+	}
+
+	clk.Advance(30 * time.Second)
+	for i := 2; i <= 3; i++ {
+		if v, err := IncrementNewTTL(tc, "hits", 1, time.Minute); err != nil || v != i {
+			t.Errorf("Expected %d, got %d, %v", i, v, err)
+		}
+	}
+	if _, got, _ := tc.GetWithExpiration("hits"); !got.Equal(want) {
+		t.Errorf("Expected the expiration to stay %v, got %v", want, got)
+	}
+
+	// 窗口结束后重新开始计数
+	clk.Advance(31 * time.Second)
+	if v, err := IncrementNewTTL(tc, "hits", 1, time.Minute); err != nil || v != 1 {
+		t.Errorf("Expected the counter to restart at 1, got %d, %v", v, err)
+	}
+	if _, got, _ := tc.GetWithExpiration("hits"); !got.Equal(clk.Now().Add(time.Minute)) {
+		t.Errorf("Expected a new TTL after expiry, got %v", got)
+	}
+
+	tc.Set("max", math.MaxInt, DefaultExpiration)
+	if _, err := IncrementNewTTL(tc, "max", 1, time.Minute); err == nil {
+		t.Error("Expected an overflow error")
+	}
+	if v, _ := tc.Get("max"); v != math.MaxInt {
+		t.Errorf("Expected the value to be unchanged, got %d", v)
+	}
+}
+
+// TestAggregateInt 测试整数CachePro的Sum、Max和Min，已过期的项目不参与计算
+func TestAggregateInt(t *testing.T) {
+	clk := newFakeClock()
+	tc := NewPro[int](DefaultExpiration, 0, nil, withClock[int](clk))
+	if _, ok := Sum(tc); ok {
+		t.Error("Expected Sum of an empty cache to report false")
+	}
+	if v, ok := Max(tc); ok || v != 0 {
+		t.Errorf("Expected Max of an empty cache to be 0, false, got %d, %v", v, ok)
+	}
+
+	tc.Set("a", 3, DefaultExpiration)
+	tc.Set("b", -2, DefaultExpiration)
+	tc.Set("c", 7, DefaultExpiration)
+	tc.Set("expired", 100, time.Second)
+	tc.SetNegative("neg", DefaultExpiration)
+	clk.Advance(2 * time.Second)
+
+	if v, ok := Sum(tc); !ok || v != 8 {
+		t.Errorf("Expected Sum 8, got %d, %v", v, ok)
+	}
+	if v, ok := Max(tc); !ok || v != 7 {
+		t.Errorf("Expected Max 7, got %d, %v", v, ok)
+	}
+	if v, ok := Min(tc); !ok || v != -2 {
+		t.Errorf("Expected Min -2, got %d, %v", v, ok)
+	}
+}
+
+// TestAggregateFloat 测试浮点数CachePro的Sum、Max和Min
+func TestAggregateFloat(t *testing.T) {
+	clk := newFakeClock()
+	tc := NewPro[float64](DefaultExpiration, 0, nil, withClock[float64](clk))
+	tc.Set("a", 1.5, DefaultExpiration)
+	tc.Set("b", 2.25, DefaultExpiration)
+	tc.Set("expired", -10, time.Second)
+	clk.Advance(2 * time.Second)
+
+	if v, ok := Sum(tc); !ok || v != 3.75 {
+		t.Errorf("Expected Sum 3.75, got %v, %v", v, ok)
+	}
+	if v, ok := Max(tc); !ok || v != 2.25 {
+		t.Errorf("Expected Max 2.25, got %v, %v", v, ok)
+	}
+	if v, ok := Min(tc); !ok || v != 1.5 {
+		t.Errorf("Expected Min 1.5, got %v, %v", v, ok)
+	}
+}
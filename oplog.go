@@ -0,0 +1,115 @@
+package cache
+
+import (
+	"bufio"
+	"encoding/gob"
+	"fmt"
+	"io"
+)
+
+// 操作日志中记录的操作类型
+const (
+	opSet byte = iota + 1
+	opDelete
+	opFlush
+)
+
+// 操作日志中的一条记录
+type opRecord[T any] struct {
+	Op   byte
+	Key  string
+	Item ItemPro[T]
+}
+
+type opLogWriter struct {
+	buf *bufio.Writer
+	enc *gob.Encoder
+	err error
+}
+
+// 记录一次变更操作，调用方必须持有写锁
+// 编码出错后停止记录，错误由FlushOpLog返回
+func (c *cachePro[T]) logOp(op byte, k string, item ItemPro[T]) {
+	l := c.opLog
+	if l.err != nil {
+		return
+	}
+	l.err = l.enc.Encode(&opRecord[T]{Op: op, Key: k, Item: item})
+}
+
+// 设置一个（可选的）io.Writer，此后每次变更CachePro的操作（Set、Delete、Flush等）
+// 都会以Gob编码追加写入其中（经过缓冲），可以用ReplayLog重放以重建状态。
+// 配合定期的快照（例如Save）即可恢复到任意时间点
+//
+// 写入是缓冲的，调用FlushOpLog才能确保所有操作都已写入w。
+// 与Save一样，当T为interface{}时需要先使用gob.Register注册具体类型
+// 设置为nil以禁用（会先刷新之前的缓冲区）
+func (c *CachePro[T]) SetOpLog(w io.Writer) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var err error
+	if c.opLog != nil {
+		err = c.flushOpLog()
+	}
+	c.opLog = nil
+	if w != nil {
+		buf := bufio.NewWriter(w)
+		c.opLog = &opLogWriter{
+			buf: buf,
+			enc: gob.NewEncoder(buf),
+		}
+	}
+	return err
+}
+
+// 将操作日志缓冲区中的内容写入底层io.Writer，并返回记录日志时遇到的第一个错误
+func (c *CachePro[T]) FlushOpLog() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.opLog == nil {
+		return nil
+	}
+	return c.flushOpLog()
+}
+
+func (c *cachePro[T]) flushOpLog() error {
+	if c.opLog.err != nil {
+		return c.opLog.err
+	}
+	return c.opLog.buf.Flush()
+}
+
+// 从io.Reader读取由SetOpLog写入的操作日志，并按顺序在CachePro上重放
+// 每条记录中的过期时间是绝对时间，因此重放后已经过期的项目仍然视为过期
+func (c *CachePro[T]) ReplayLog(r io.Reader) error {
+	c.mustBeOpen()
+	dec := gob.NewDecoder(r)
+	for {
+		var rec opRecord[T]
+		if err := dec.Decode(&rec); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+		var flushed map[string]ItemPro[T]
+		c.mu.Lock()
+		switch rec.Op {
+		case opSet:
+			c.overwrite(rec.Key, rec.Item)
+		case opDelete:
+			c.remove(rec.Key)
+		case opFlush:
+			flushed = c.items
+			c.resetItems(map[string]ItemPro[T]{})
+		default:
+			c.mu.Unlock()
+			return fmt.Errorf("Unknown operation %d in log", rec.Op)
+		}
+		c.mu.Unlock()
+		// 与Flush相同，在释放写锁之后将被清空的项目传给delFunc
+		for _, v := range flushed {
+			c.release(v)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package cache
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+// TestOpLogReplay 测试重放操作日志重建缓存状态
+func TestOpLogReplay(t *testing.T) {
+	tc := NewPro[string](DefaultExpiration, 0, nil)
+	tc.Set("before", "not logged", DefaultExpiration)
+
+	buf := &bytes.Buffer{}
+	if err := tc.SetOpLog(buf); err != nil {
+		t.Fatalf("SetOpLog failed: %v", err)
+	}
+	tc.Set("a", "alpha", DefaultExpiration)
+	tc.Set("b", "beta", time.Hour)
+	tc.Set("c", "gamma", DefaultExpiration)
+	tc.Delete("c")
+	tc.Add("d", "delta", DefaultExpiration)
+	tc.Replace("a", "alpha2", DefaultExpiration)
+	if err := tc.FlushOpLog(); err != nil {
+		t.Fatalf("FlushOpLog failed: %v", err)
+	}
+
+	oc := NewPro[string](DefaultExpiration, 0, nil)
+	if err := oc.ReplayLog(buf); err != nil {
+		t.Fatalf("ReplayLog failed: %v", err)
+	}
+
+	want := tc.Items()
+	delete(want, "before")
+	got := oc.Items()
+	if len(got) != len(want) {
+		t.Fatalf("Expected %d items after replay, got %d", len(want), len(got))
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("Expected %s to be %+v after replay, got %+v", k, v, got[k])
+		}
+	}
+}
+
+// TestOpLogFlush 测试Flush也会被记录
+func TestOpLogFlush(t *testing.T) {
+	tc := NewPro[int](DefaultExpiration, 0, nil)
+	buf := &bytes.Buffer{}
+	tc.SetOpLog(buf)
+	tc.Set("a", 1, DefaultExpiration)
+	tc.Flush()
+	tc.Set("b", 2, DefaultExpiration)
+	if err := tc.SetOpLog(nil); err != nil {
+		t.Fatalf("SetOpLog(nil) failed: %v", err)
+	}
+
+	oc := NewPro[int](DefaultExpiration, 0, nil)
+	if err := oc.ReplayLog(buf); err != nil {
+		t.Fatalf("ReplayLog failed: %v", err)
+	}
+	if _, found := oc.Get("a"); found {
+		t.Error("a was found after replaying a flush")
+	}
+	if b, found := oc.Get("b"); !found || b != 2 {
+		t.Errorf("Expected b to be 2, got %v, %v", b, found)
+	}
+}
+
+// TestOpLogReplayDelFunc 测试重放时被覆盖或被Flush清空的值会传给delFunc
+func TestOpLogReplayDelFunc(t *testing.T) {
+	tc := NewPro[int](DefaultExpiration, 0, nil)
+	buf := &bytes.Buffer{}
+	tc.SetOpLog(buf)
+	tc.Set("a", 1, DefaultExpiration)
+	tc.Set("a", 2, DefaultExpiration)
+	tc.Flush()
+	tc.Set("b", 3, DefaultExpiration)
+	if err := tc.SetOpLog(nil); err != nil {
+		t.Fatalf("SetOpLog(nil) failed: %v", err)
+	}
+
+	var deleted []int
+	oc := NewPro[int](DefaultExpiration, 0, func(v int) { deleted = append(deleted, v) })
+	if err := oc.ReplayLog(buf); err != nil {
+		t.Fatalf("ReplayLog failed: %v", err)
+	}
+	if len(deleted) != 2 || deleted[0] != 1 || deleted[1] != 2 {
+		t.Errorf("Expected delFunc to be called with 1 then 2, got %v", deleted)
+	}
+}
@@ -0,0 +1,122 @@
+package cache
+
+import (
+	"time"
+)
+
+// OptionPro 是创建CachePro时可选的配置项，传给NewPro、NewFromPro等构造函数
+type OptionPro[T any] func(*cachePro[T])
+
+// 启用访问时间跟踪：每次写入和Get命中时更新项目的LastAccess，可通过GetMeta读取
+// 启用后Get需要获取写锁，会降低并发读取的性能，因此默认关闭
+func WithAccessTracking[T any]() OptionPro[T] {
+	return func(c *cachePro[T]) {
+		c.trackAccess = true
+	}
+}
+
+// 为每个项目的过期时间加上随机抖动：持续时间d被随机调整为d的(1-fraction)到(1+fraction)倍之间，
+// 以免同时写入的大量项目在同一时刻过期。永不过期的项目不受影响。fraction会被限制在[0, 1]之间
+func WithExpirationJitter[T any](fraction float64) OptionPro[T] {
+	return func(c *cachePro[T]) {
+		c.jitter = min(max(fraction, 0), 1)
+	}
+}
+
+// 持续时间为0（DefaultExpiration）时的解释方式，参见WithZeroDurationPolicy
+type ZeroDurationPolicy int
+
+const (
+	// 持续时间为0时使用CachePro的默认过期时间（默认）
+	ZeroMeansDefault ZeroDurationPolicy = iota
+	// 持续时间为0时项目永不过期，与NoExpiration相同
+	ZeroMeansNoExpiration
+)
+
+// 设置Set、Compute等方法收到持续时间0时的解释方式。默认为ZeroMeansDefault，
+// 即使用创建CachePro时指定的默认过期时间；习惯用0表示"永久缓存"时可以使用ZeroMeansNoExpiration。
+// SetDefault不受该选项影响，始终使用默认过期时间
+func WithZeroDurationPolicy[T any](p ZeroDurationPolicy) OptionPro[T] {
+	return func(c *cachePro[T]) {
+		c.zeroPolicy = p
+	}
+}
+
+// CachePro被垃圾回收时调用Close，对剩余的每个项目调用delFunc，保证值持有的资源（例如文件句柄）
+// 即使没有显式关闭也会被释放。回调在终结器goroutine中运行，不能依赖它们的执行时间；
+// 需要确定的释放时机时请显式调用Close
+func WithCloseOnFinalize[T any]() OptionPro[T] {
+	return func(c *cachePro[T]) {
+		c.closeOnFinalize = true
+	}
+}
+
+// 不为CachePro设置终结器。默认情况下清理程序和写回goroutine在CachePro被垃圾回收时才停止，
+// 停止的时间无法预测；使用此选项后必须调用Close来停止它们，否则这些goroutine会一直存在。
+// 适用于频繁创建和丢弃CachePro、需要确定地回收goroutine（例如检测goroutine泄漏的测试）的场景。
+// 此选项会使WithCloseOnFinalize失效
+func WithoutFinalizer[T any]() OptionPro[T] {
+	return func(c *cachePro[T]) {
+		c.noFinalizer = true
+	}
+}
+
+// 设置GetOrDefault在键不存在或已过期时返回的默认值，未设置时为T的零值
+func WithDefaultValue[T any](v T) OptionPro[T] {
+	return func(c *cachePro[T]) {
+		c.defaultValue = v
+	}
+}
+
+// GetMeta 从CachePro返回项目及其元数据：过期时间（永不过期时为time.Time的零值）、
+// 最近一次访问时间（未启用WithAccessTracking时为time.Time的零值）以及是否找到未过期的键
+// GetMeta本身不会更新访问时间
+func (c *CachePro[T]) GetMeta(k string) (value T, expiresAt time.Time, lastAccess time.Time, found bool) {
+	c.mu.RLock()
+	item, ok := c.items[k]
+	c.mu.RUnlock()
+	if !ok || item.Negative || c.expired(item) {
+		return value, expiresAt, lastAccess, false
+	}
+	if item.Expiration > 0 {
+		expiresAt = time.Unix(0, item.Expiration)
+	}
+	if item.LastAccess > 0 {
+		lastAccess = time.Unix(0, item.LastAccess)
+	}
+	return item.Object, expiresAt, lastAccess, true
+}
+
+// 启用访问时间跟踪时Get的实现，在写锁内更新LastAccess
+func (c *cachePro[T]) getTracked(k string) (T, bool) {
+	now := c.clock.Now().UnixNano()
+	c.mu.Lock()
+	item, found := c.items[k]
+	if found && c.lazyEvict && item.Expiration > 0 && now > item.Expiration && now > item.HardExpiration {
+		c.misses.Add(1)
+		c.unlockAndNotifyExpired(k, item)
+		if c.loader != nil {
+			return c.loadMissing(k)
+		}
+		var zero T
+		return zero, false
+	}
+	if !found || item.Negative || (item.Expiration > 0 && now > item.Expiration) {
+		c.mu.Unlock()
+		c.misses.Add(1)
+		if c.loader != nil && (!found || !item.Negative) {
+			return c.loadMissing(k)
+		}
+		var zero T
+		return zero, false
+	}
+	item.LastAccess = now
+	// 只更新访问时间，不经过put，以免记录到操作日志或转发给镜像目标
+	c.items[k] = item
+	if c.policy != nil {
+		c.policy.RecordAccess(k)
+	}
+	c.mu.Unlock()
+	c.hits.Add(1)
+	return item.Object, true
+}
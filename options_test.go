@@ -0,0 +1,152 @@
+package cache
+
+import (
+	"runtime"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// TestAccessTracking 测试只有启用访问时间跟踪时读取才会更新LastAccess
+func TestAccessTracking(t *testing.T) {
+	tc := NewPro[int](DefaultExpiration, 0, nil, WithAccessTracking[int]())
+	tc.Set("a", 1, time.Hour)
+
+	v, exp, first, found := tc.GetMeta("a")
+	if !found || v != 1 {
+		t.Fatalf("Expected a=1, got %d, %v", v, found)
+	}
+	if exp.IsZero() || first.IsZero() {
+		t.Fatalf("Expected expiration and last access to be set, got %v, %v", exp, first)
+	}
+
+	<-time.After(2 * time.Millisecond)
+	tc.Get("a")
+	_, _, second, _ := tc.GetMeta("a")
+	if !second.After(first) {
+		t.Errorf("Expected last access to advance after Get, got %v then %v", first, second)
+	}
+
+	<-time.After(2 * time.Millisecond)
+	_, _, third, _ := tc.GetMeta("a")
+	if !third.Equal(second) {
+		t.Errorf("Expected GetMeta not to update last access, got %v then %v", second, third)
+	}
+
+	nc := NewPro[int](DefaultExpiration, 0, nil)
+	nc.Set("a", 1, DefaultExpiration)
+	nc.Get("a")
+	_, exp, last, found := nc.GetMeta("a")
+	if !found || !exp.IsZero() || !last.IsZero() {
+		t.Errorf("Expected zero expiration and last access without tracking, got %v, %v, %v", exp, last, found)
+	}
+	if _, _, _, found := nc.GetMeta("missing"); found {
+		t.Error("GetMeta found a missing key")
+	}
+}
+
+// TestExpirationJitter 测试抖动使同时写入的项目的过期时间分散在一个范围内
+func TestExpirationJitter(t *testing.T) {
+	clk := newFakeClock()
+	tc := NewPro[int](DefaultExpiration, 0, nil, withClock[int](clk), WithExpirationJitter[int](0.1))
+	for i := 0; i < 1000; i++ {
+		tc.Set(strconv.Itoa(i), i, time.Hour)
+	}
+	tc.Set("forever", 0, NoExpiration)
+
+	lo, hi := clk.Now().Add(54*time.Minute), clk.Now().Add(66*time.Minute)
+	var minExp, maxExp time.Time
+	for i := 0; i < 1000; i++ {
+		_, exp, _ := tc.GetWithExpiration(strconv.Itoa(i))
+		if exp.Before(lo) || exp.After(hi) {
+			t.Fatalf("Expiration %v outside of ±10%% of 1h", exp.Sub(clk.Now()))
+		}
+		if minExp.IsZero() || exp.Before(minExp) {
+			minExp = exp
+		}
+		if exp.After(maxExp) {
+			maxExp = exp
+		}
+	}
+	if maxExp.Sub(minExp) < 6*time.Minute {
+		t.Errorf("Expected expirations to be spread out, got range %v", maxExp.Sub(minExp))
+	}
+	if _, exp, _ := tc.GetWithExpiration("forever"); !exp.IsZero() {
+		t.Errorf("Expected jitter not to apply to NoExpiration, got %v", exp)
+	}
+}
+
+// TestZeroDurationPolicy 测试两种策略下相同的调用序列得到不同的过期时间
+func TestZeroDurationPolicy(t *testing.T) {
+	clk := newFakeClock()
+	run := func(p ZeroDurationPolicy) (set, compute, setDefault time.Time) {
+		tc := NewPro[int](time.Hour, 0, nil, withClock[int](clk), WithZeroDurationPolicy[int](p))
+		tc.Set("a", 1, 0)
+		tc.ComputeWithExpiration("b", func(a, b int) int { return a + b }, 1, 0)
+		tc.SetDefault("c", 1)
+		_, set, _ = tc.GetWithExpiration("a")
+		_, compute, _ = tc.GetWithExpiration("b")
+		_, setDefault, _ = tc.GetWithExpiration("c")
+		return
+	}
+
+	hour := clk.Now().Add(time.Hour)
+	set, compute, setDefault := run(ZeroMeansDefault)
+	if !set.Equal(hour) || !compute.Equal(hour) {
+		t.Errorf("ZeroMeansDefault: expected %v, got Set %v and Compute %v", hour, set, compute)
+	}
+	if !setDefault.Equal(hour) {
+		t.Errorf("ZeroMeansDefault: expected SetDefault to use %v, got %v", hour, setDefault)
+	}
+
+	set, compute, setDefault = run(ZeroMeansNoExpiration)
+	if !set.IsZero() || !compute.IsZero() {
+		t.Errorf("ZeroMeansNoExpiration: expected no expiration, got Set %v and Compute %v", set, compute)
+	}
+	if !setDefault.Equal(hour) {
+		t.Errorf("ZeroMeansNoExpiration: expected SetDefault to use %v, got %v", hour, setDefault)
+	}
+}
+
+// TestWithDefaultValue 测试GetOrDefault未命中时返回默认值，命中时返回保存的值
+func TestWithDefaultValue(t *testing.T) {
+	clk := newFakeClock()
+	tc := NewPro[string](DefaultExpiration, 0, nil, WithDefaultValue("fallback"), withClock[string](clk))
+	tc.Set("a", "stored", DefaultExpiration)
+	tc.Set("empty", "", DefaultExpiration)
+	tc.Set("expired", "old", time.Second)
+	clk.Advance(2 * time.Second)
+
+	for k, want := range map[string]string{
+		"a":       "stored",
+		"empty":   "",
+		"missing": "fallback",
+		"expired": "fallback",
+	} {
+		if v := tc.GetOrDefault(k); v != want {
+			t.Errorf("Expected %s to be %q, got %q", k, want, v)
+		}
+	}
+
+	if v := NewPro[int](DefaultExpiration, 0, nil).GetOrDefault("missing"); v != 0 {
+		t.Errorf("Expected the zero value without WithDefaultValue, got %d", v)
+	}
+}
+
+// TestWithoutFinalizer 测试不设置终结器时Close立即回收清理程序goroutine
+func TestWithoutFinalizer(t *testing.T) {
+	before := runtime.NumGoroutine()
+	tc := NewPro[int](DefaultExpiration, time.Millisecond, nil, WithoutFinalizer[int]())
+	if n := runtime.NumGoroutine(); n <= before {
+		t.Fatalf("Expected a janitor goroutine, got %d goroutines (was %d)", n, before)
+	}
+
+	tc.Close()
+	deadline := time.Now().Add(time.Second)
+	for runtime.NumGoroutine() > before {
+		if time.Now().After(deadline) {
+			t.Fatalf("Janitor goroutine still running after Close: %d goroutines (was %d)", runtime.NumGoroutine(), before)
+		}
+		runtime.Gosched()
+	}
+}
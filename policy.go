@@ -0,0 +1,136 @@
+package cache
+
+import (
+	"container/list"
+	"sync"
+)
+
+// Policy 决定有内存上限的CachePro（参见NewProWithMemLimit）超出上限时先驱逐哪个项目
+//
+// 所有方法都在持有CachePro的锁时调用，因此不能回调CachePro的方法。
+// Get命中时只持有读锁，RecordAccess可能被并发调用，实现必须自行同步
+type Policy interface {
+	// 项目被读取
+	RecordAccess(key string)
+	// 项目被写入（包括覆盖已存在的项目）
+	RecordInsert(key string)
+	// 项目被删除（包括过期清理和驱逐）
+	RecordRemove(key string)
+	// 选出下一个应被驱逐的键并不再跟踪它，没有可驱逐的键时返回false
+	Evict() (key string, ok bool)
+}
+
+// 指定NewProWithMemLimit创建的CachePro使用的驱逐策略，默认为NewLRUPolicy
+// 同一个Policy不能由多个CachePro共用。对没有内存上限的CachePro无效
+func WithPolicy[T any](p Policy) OptionPro[T] {
+	return func(c *cachePro[T]) {
+		c.policy = p
+	}
+}
+
+// 按访问频率排列的键，访问次数相同时按最近使用顺序排列
+type lfuPolicy struct {
+	mu    sync.Mutex
+	elems map[string]*list.Element
+	// 每个访问次数对应的键列表，最近访问的在前
+	freqs   map[int]*list.List
+	minFreq int
+}
+
+type lfuEntry struct {
+	key  string
+	freq int
+}
+
+// NewLFUPolicy 返回按最不经常使用（LFU）顺序驱逐的Policy：写入和读取都会增加键的访问次数，
+// 总是驱逐访问次数最少的键，次数相同时驱逐其中最久未使用的一个。
+// 适用于少数热点键被反复读取、其余键只读写一次的负载，此时LRU会被大量冷键挤掉热点键
+func NewLFUPolicy() Policy {
+	return &lfuPolicy{
+		elems: make(map[string]*list.Element),
+		freqs: make(map[int]*list.List),
+	}
+}
+
+func (p *lfuPolicy) RecordAccess(k string) {
+	p.mu.Lock()
+	if e, ok := p.elems[k]; ok {
+		p.increment(e)
+	}
+	p.mu.Unlock()
+}
+
+func (p *lfuPolicy) RecordInsert(k string) {
+	p.mu.Lock()
+	if e, ok := p.elems[k]; ok {
+		p.increment(e)
+	} else {
+		p.elems[k] = p.list(1).PushFront(&lfuEntry{key: k, freq: 1})
+		p.minFreq = 1
+	}
+	p.mu.Unlock()
+}
+
+func (p *lfuPolicy) RecordRemove(k string) {
+	p.mu.Lock()
+	if e, ok := p.elems[k]; ok {
+		p.unlink(e)
+		delete(p.elems, k)
+	}
+	p.mu.Unlock()
+}
+
+func (p *lfuPolicy) Evict() (string, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(p.elems) == 0 {
+		return "", false
+	}
+	l, ok := p.freqs[p.minFreq]
+	if !ok {
+		// minFreq的列表已因删除而清空，重新查找最小的访问次数
+		first := true
+		for f := range p.freqs {
+			if first || f < p.minFreq {
+				p.minFreq, first = f, false
+			}
+		}
+		l = p.freqs[p.minFreq]
+	}
+	e := l.Back()
+	k := e.Value.(*lfuEntry).key
+	p.unlink(e)
+	delete(p.elems, k)
+	return k, true
+}
+
+// 将e的访问次数加1，调用方必须持有p.mu
+func (p *lfuPolicy) increment(e *list.Element) {
+	entry := e.Value.(*lfuEntry)
+	p.unlink(e)
+	if entry.freq == p.minFreq && p.freqs[entry.freq] == nil {
+		p.minFreq++
+	}
+	entry.freq++
+	p.elems[entry.key] = p.list(entry.freq).PushFront(entry)
+}
+
+// 从所在的列表中移除e，列表为空时删除该列表，调用方必须持有p.mu
+func (p *lfuPolicy) unlink(e *list.Element) {
+	f := e.Value.(*lfuEntry).freq
+	l := p.freqs[f]
+	l.Remove(e)
+	if l.Len() == 0 {
+		delete(p.freqs, f)
+	}
+}
+
+// 返回访问次数f对应的列表，不存在时创建，调用方必须持有p.mu
+func (p *lfuPolicy) list(f int) *list.List {
+	l, ok := p.freqs[f]
+	if !ok {
+		l = list.New()
+		p.freqs[f] = l
+	}
+	return l
+}
@@ -0,0 +1,101 @@
+package cache
+
+import (
+	"strconv"
+	"testing"
+)
+
+// 以相同的访问模式运行一个最多容纳3个项目的CachePro：两个热点键被反复读取，之后写入一串只写一次的冷键
+// 返回仍然存在的热点键数量
+func runSkewedWorkload(opts ...OptionPro[int]) int {
+	tc := NewProWithMemLimit[int](DefaultExpiration, 0, 3, func(int) int64 { return 1 }, nil, opts...)
+	tc.Set("hot1", 1, DefaultExpiration)
+	tc.Set("hot2", 2, DefaultExpiration)
+	for i := 0; i < 10; i++ {
+		tc.Get("hot1")
+		tc.Get("hot2")
+	}
+	for i := 0; i < 5; i++ {
+		tc.Set("cold"+strconv.Itoa(i), i, DefaultExpiration)
+	}
+	n := 0
+	for _, k := range []string{"hot1", "hot2"} {
+		if _, found := tc.Get(k); found {
+			n++
+		}
+	}
+	return n
+}
+
+// TestLFUPolicyKeepsHotKeys 测试在偏斜的访问模式下LFU保留热点键，而LRU会驱逐它们
+func TestLFUPolicyKeepsHotKeys(t *testing.T) {
+	if n := runSkewedWorkload(); n != 0 {
+		t.Errorf("Expected LRU to evict both hot keys, %d survived", n)
+	}
+	if n := runSkewedWorkload(WithPolicy[int](NewLRUPolicy())); n != 0 {
+		t.Errorf("Expected the explicit LRU policy to evict both hot keys, %d survived", n)
+	}
+	if n := runSkewedWorkload(WithPolicy[int](NewLFUPolicy())); n != 2 {
+		t.Errorf("Expected LFU to keep both hot keys, %d survived", n)
+	}
+}
+
+// TestLFUPolicyEvictionOrder 测试LFU按访问次数驱逐，次数相同时驱逐最久未使用的键
+func TestLFUPolicyEvictionOrder(t *testing.T) {
+	p := NewLFUPolicy()
+	for _, k := range []string{"a", "b", "c", "d"} {
+		p.RecordInsert(k)
+	}
+	p.RecordAccess("a")
+	p.RecordAccess("a")
+	p.RecordAccess("c")
+	p.RecordRemove("d")
+	p.RecordAccess("missing")
+
+	for _, want := range []string{"b", "c", "a"} {
+		if k, ok := p.Evict(); !ok || k != want {
+			t.Errorf("Expected to evict %s, got %s, %v", want, k, ok)
+		}
+	}
+	if k, ok := p.Evict(); ok {
+		t.Errorf("Expected nothing to evict, got %s", k)
+	}
+
+	// 删除访问次数最少的键后仍能找到下一个最少的键
+	p.RecordInsert("x")
+	p.RecordInsert("y")
+	p.RecordAccess("y")
+	p.RecordRemove("x")
+	if k, ok := p.Evict(); !ok || k != "y" {
+		t.Errorf("Expected to evict y, got %s, %v", k, ok)
+	}
+}
+
+// TestLFUPolicyKeepsNewItem 测试刚写入的项目访问次数最少时也不会被这次写入驱逐
+func TestLFUPolicyKeepsNewItem(t *testing.T) {
+	tc := NewProWithMemLimit[int](DefaultExpiration, 0, 2, func(int) int64 { return 1 }, nil, WithPolicy[int](NewLFUPolicy()))
+	tc.Set("a", 1, DefaultExpiration)
+	tc.Get("a")
+	tc.Set("b", 2, DefaultExpiration)
+	tc.Get("b")
+	tc.Set("c", 3, DefaultExpiration)
+
+	// 用Items检查以免读取改变访问次数
+	items := tc.Items()
+	if _, found := items["c"]; !found {
+		t.Error("The newly written item was evicted")
+	}
+	if _, found := items["a"]; found || len(items) != 2 || tc.MemoryBytes() != 2 {
+		t.Errorf("Expected a to be evicted, got %d items, %d bytes", len(items), tc.MemoryBytes())
+	}
+
+	// c的访问次数最少，下一次写入时被驱逐
+	tc.Set("d", 4, DefaultExpiration)
+	items = tc.Items()
+	if _, found := items["c"]; found {
+		t.Error("c should have been evicted as the least frequently used item")
+	}
+	if _, found := items["b"]; !found {
+		t.Error("b should have survived eviction")
+	}
+}
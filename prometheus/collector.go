@@ -0,0 +1,53 @@
+// Package prometheus 将goCachePro的运行统计导出为Prometheus指标。
+// 它是一个独立的模块，只有需要Prometheus的使用者才会引入client_golang依赖
+package prometheus
+
+import (
+	cache "github.com/jxc1690/goCachePro"
+	prom "github.com/prometheus/client_golang/prometheus"
+)
+
+// Source 是可以提供运行统计的缓存，*cache.CachePro[T]对任意T都满足此接口
+type Source interface {
+	Metrics() cache.Metrics
+}
+
+type collector struct {
+	src       Source
+	hits      *prom.Desc
+	misses    *prom.Desc
+	evictions *prom.Desc
+	items     *prom.Desc
+}
+
+// Collector 返回一个prometheus.Collector，每次抓取时从src读取Metrics，
+// 导出<namespace>_hits_total、<namespace>_misses_total、<namespace>_evictions_total和<namespace>_items。
+// 计数器与CachePro.Metrics使用同一组原子计数器。constLabels可以为nil，
+// 在同一个Registry中注册多个缓存时用它区分（例如{"cache": "users"}）
+func Collector(namespace string, src Source, constLabels prom.Labels) prom.Collector {
+	desc := func(name, help string) *prom.Desc {
+		return prom.NewDesc(prom.BuildFQName(namespace, "", name), help, nil, constLabels)
+	}
+	return &collector{
+		src:       src,
+		hits:      desc("hits_total", "Number of cache reads that found a live item."),
+		misses:    desc("misses_total", "Number of cache reads that found no live item."),
+		evictions: desc("evictions_total", "Number of items deleted, expired or evicted."),
+		items:     desc("items", "Number of items in the cache, including expired items not yet cleaned up."),
+	}
+}
+
+func (c *collector) Describe(ch chan<- *prom.Desc) {
+	ch <- c.hits
+	ch <- c.misses
+	ch <- c.evictions
+	ch <- c.items
+}
+
+func (c *collector) Collect(ch chan<- prom.Metric) {
+	m := c.src.Metrics()
+	ch <- prom.MustNewConstMetric(c.hits, prom.CounterValue, float64(m.Hits))
+	ch <- prom.MustNewConstMetric(c.misses, prom.CounterValue, float64(m.Misses))
+	ch <- prom.MustNewConstMetric(c.evictions, prom.CounterValue, float64(m.Evictions))
+	ch <- prom.MustNewConstMetric(c.items, prom.GaugeValue, float64(m.Items))
+}
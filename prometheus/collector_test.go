@@ -0,0 +1,51 @@
+package prometheus
+
+import (
+	"testing"
+
+	cache "github.com/jxc1690/goCachePro"
+	prom "github.com/prometheus/client_golang/prometheus"
+)
+
+// TestCollector 测试抓取到的指标与CachePro的运行统计一致
+func TestCollector(t *testing.T) {
+	tc := cache.NewPro[int](cache.DefaultExpiration, 0, nil)
+	reg := prom.NewRegistry()
+	reg.MustRegister(Collector("gocache", tc, prom.Labels{"cache": "test"}))
+
+	tc.Set("a", 1, cache.DefaultExpiration)
+	tc.Set("b", 2, cache.DefaultExpiration)
+	tc.Get("a")
+	tc.Get("a")
+	tc.Get("missing")
+	tc.Delete("b")
+
+	families, err := reg.Gather()
+	if err != nil {
+		t.Fatalf("Gather failed: %v", err)
+	}
+	got := map[string]float64{}
+	for _, f := range families {
+		for _, m := range f.GetMetric() {
+			if l := m.GetLabel(); len(l) != 1 || l[0].GetValue() != "test" {
+				t.Errorf("Expected the cache label on %s, got %v", f.GetName(), l)
+			}
+			if c := m.GetCounter(); c != nil {
+				got[f.GetName()] = c.GetValue()
+			} else {
+				got[f.GetName()] = m.GetGauge().GetValue()
+			}
+		}
+	}
+	want := map[string]float64{
+		"gocache_hits_total":      2,
+		"gocache_misses_total":    1,
+		"gocache_evictions_total": 1,
+		"gocache_items":           1,
+	}
+	for name, v := range want {
+		if got[name] != v {
+			t.Errorf("Expected %s to be %v, got %v", name, v, got[name])
+		}
+	}
+}
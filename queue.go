@@ -0,0 +1,52 @@
+package cache
+
+import (
+	"time"
+)
+
+// PushBack 在同一个写锁内将v追加到键k保存的切片末尾，并以过期时间d存储结果
+// 如果键不存在或已过期，则创建一个只包含v的新切片。每次调用都会以d重新计算过期时间
+func PushBack[T any](c *CachePro[[]T], k string, v T, d time.Duration) {
+	c.mustBeOpen()
+	c.mu.Lock()
+	cur, found := c.get(k)
+	item := ItemPro[[]T]{
+		Object:     append(cur, v),
+		Expiration: c.expiration(d),
+	}
+	// 新切片可能与旧切片共用底层数组，只有旧值已过期时才传给delFunc
+	if found {
+		c.put(k, item)
+	} else {
+		c.overwrite(k, item)
+	}
+	c.unlockAndEvict(k)
+}
+
+// PopFront 在同一个写锁内移除并返回键k保存的切片的第一个元素，保持项目原有的过期时间
+// 如果键不存在、已过期或切片为空，则返回零值和false。切片被取空后该项目会被删除（触发onEvicted）
+func PopFront[T any](c *CachePro[[]T], k string) (T, bool) {
+	c.mustBeOpen()
+	c.mu.Lock()
+	cur, found := c.get(k)
+	if !found || len(cur) == 0 {
+		c.mu.Unlock()
+		var zero T
+		return zero, false
+	}
+	head := cur[0]
+	if len(cur) == 1 {
+		ov, evicted := c.delete(k)
+		c.mu.Unlock()
+		if evicted {
+			c.notifyEvicted(k, ov)
+		}
+		return head, true
+	}
+	c.put(k, ItemPro[[]T]{
+		Object:     cur[1:],
+		Expiration: c.items[k].Expiration,
+	})
+	c.mu.Unlock()
+	return head, true
+}
@@ -0,0 +1,70 @@
+package cache
+
+import (
+	"sync"
+	"testing"
+)
+
+// TestPushBackPopFront 测试切片队列的先进先出顺序以及取空后删除
+func TestPushBackPopFront(t *testing.T) {
+	tc := NewPro[[]int](DefaultExpiration, 0, nil)
+	if _, ok := PopFront(tc, "q"); ok {
+		t.Error("PopFront on a missing key returned ok")
+	}
+	for i := 0; i < 3; i++ {
+		PushBack(tc, "q", i, DefaultExpiration)
+	}
+	for i := 0; i < 3; i++ {
+		v, ok := PopFront(tc, "q")
+		if !ok || v != i {
+			t.Errorf("Expected %d, got %d, %v", i, v, ok)
+		}
+	}
+	if _, found := tc.Get("q"); found {
+		t.Error("Empty queue was not deleted")
+	}
+	tc.Set("empty", []int{}, DefaultExpiration)
+	if _, ok := PopFront(tc, "empty"); ok {
+		t.Error("PopFront on an empty slice returned ok")
+	}
+}
+
+// TestPushBackPopFrontConcurrent 测试并发生产和消费时每个元素只被取出一次，且同一生产者的元素按入队顺序取出
+func TestPushBackPopFrontConcurrent(t *testing.T) {
+	tc := NewPro[[]int](DefaultExpiration, 0, nil)
+	const producers, perProducer = 4, 200
+
+	var wg sync.WaitGroup
+	for p := 0; p < producers; p++ {
+		wg.Add(1)
+		go func(p int) {
+			defer wg.Done()
+			for i := 0; i < perProducer; i++ {
+				PushBack(tc, "q", p*perProducer+i, DefaultExpiration)
+			}
+		}(p)
+	}
+
+	var got []int
+	for len(got) < producers*perProducer {
+		if v, ok := PopFront(tc, "q"); ok {
+			got = append(got, v)
+		}
+	}
+	wg.Wait()
+	if _, ok := PopFront(tc, "q"); ok {
+		t.Error("Queue had extra elements")
+	}
+
+	last := make([]int, producers)
+	for p := range last {
+		last[p] = -1
+	}
+	for _, v := range got {
+		p, i := v/perProducer, v%perProducer
+		if i <= last[p] {
+			t.Fatalf("Producer %d: element %d popped after %d", p, i, last[p])
+		}
+		last[p] = i
+	}
+}
@@ -0,0 +1,50 @@
+package cache
+
+import (
+	"time"
+)
+
+// CacheReader 是CachePro的只读视图，参见ReadOnly
+type CacheReader[T any] interface {
+	Get(k string) (T, bool)
+	GetWithExpiration(k string) (T, time.Time, bool)
+	Has(k string) bool
+	Keys() []string
+	ItemCount() int
+	Range(f func(k string, v T) bool)
+}
+
+// 只暴露读取方法的包装，避免调用方通过类型断言取回*CachePro
+type readOnlyPro[T any] struct {
+	c *CachePro[T]
+}
+
+// ReadOnly 返回CachePro的只读视图，可以交给只应读取缓存的代码使用
+// 视图直接读取同一个CachePro，不会复制数据，因此总能看到最新的写入
+func (c *CachePro[T]) ReadOnly() CacheReader[T] {
+	return readOnlyPro[T]{c}
+}
+
+func (r readOnlyPro[T]) Get(k string) (T, bool) {
+	return r.c.Get(k)
+}
+
+func (r readOnlyPro[T]) GetWithExpiration(k string) (T, time.Time, bool) {
+	return r.c.GetWithExpiration(k)
+}
+
+func (r readOnlyPro[T]) Has(k string) bool {
+	return r.c.Has(k)
+}
+
+func (r readOnlyPro[T]) Keys() []string {
+	return r.c.Keys()
+}
+
+func (r readOnlyPro[T]) ItemCount() int {
+	return r.c.ItemCount()
+}
+
+func (r readOnlyPro[T]) Range(f func(k string, v T) bool) {
+	r.c.Range(f)
+}
@@ -0,0 +1,56 @@
+package cache
+
+import (
+	"sort"
+	"testing"
+)
+
+var _ CacheReader[int] = (*CachePro[int])(nil)
+
+// TestReadOnly 测试只读视图反映原CachePro的实时更新
+func TestReadOnly(t *testing.T) {
+	tc := NewPro[int](DefaultExpiration, 0, nil)
+	r := tc.ReadOnly()
+	if _, ok := r.(*CachePro[int]); ok {
+		t.Error("ReadOnly view can be asserted back to *CachePro")
+	}
+	if r.ItemCount() != 0 {
+		t.Errorf("Expected empty view, got %d items", r.ItemCount())
+	}
+
+	tc.Set("a", 1, DefaultExpiration)
+	tc.Set("b", 2, DefaultExpiration)
+	if v, found := r.Get("a"); !found || v != 1 {
+		t.Errorf("Expected a=1 through the view, got %d, %v", v, found)
+	}
+	if !r.Has("b") || r.ItemCount() != 2 {
+		t.Error("View does not reflect writes to the original")
+	}
+	keys := r.Keys()
+	sort.Strings(keys)
+	if len(keys) != 2 || keys[0] != "a" || keys[1] != "b" {
+		t.Errorf("Expected keys [a b], got %v", keys)
+	}
+
+	sum := 0
+	r.Range(func(k string, v int) bool {
+		sum += v
+		return true
+	})
+	if sum != 3 {
+		t.Errorf("Expected Range to visit both items, got sum %d", sum)
+	}
+	visited := 0
+	r.Range(func(string, int) bool {
+		visited++
+		return false
+	})
+	if visited != 1 {
+		t.Errorf("Expected Range to stop after f returns false, visited %d", visited)
+	}
+
+	tc.Delete("a")
+	if r.Has("a") {
+		t.Error("View does not reflect deletes from the original")
+	}
+}
@@ -2,11 +2,16 @@ package cache
 
 import (
 	"crypto/rand"
+	"fmt"
+	"hash/maphash"
+	"io"
 	"math"
 	"math/big"
 	insecurerand "math/rand"
 	"os"
 	"runtime"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -15,16 +20,126 @@ import (
 // 选择桶的开销导致缓存操作在总缓存大小较小时比标准缓存慢约两倍，而在较大时更快。
 //
 // 有关一些基准测试，请参见cache_test.go。
+//
+// 分片缓存的构造函数、选项和类型都有意保持未导出，只供包内部和测试使用，不属于公开API：
+// 它们的签名和行为可能随时改变，在稳定之前不会承诺兼容性。
 
 type unexportedShardedCache struct {
 	*shardedCache
 }
 
 type shardedCache struct {
-	seed    uint32
+	// 当前的分片表，Resize会换上新的分片表。普通操作不获取全局锁，只获取所在分片的gate
+	table atomic.Pointer[shardTable]
+	// 串行化Resize
+	resizeMu sync.Mutex
+	seed     uint32
+	janitor  *shardedJanitor
+	hash     func(seed uint32, k string) uint32
+	hardened bool
+	hashSeed maphash.Seed
+
+	// 以下字段只在创建时使用，决定如何获取种子
+	seedSet      bool
+	seedPolicy   seedFailurePolicy
+	fallbackSeed uint32
+	logf         func(msg string)
+}
+
+// 分片及其gate。普通操作在访问分片期间持有该分片gate的读锁；
+// Resize获取旧表所有gate的写锁，迁移项目并换上新表后将旧表标记为retired，
+// 之后在旧表gate上等待的操作会发现retired并改为访问新表，因此不会写入已经迁移的旧分片
+type shardTable struct {
 	m       uint32
 	cs      []*cache
-	janitor *shardedJanitor
+	gates   []sync.RWMutex
+	retired bool // 由所有gate的写锁保护
+}
+
+func newShardTable(n int, de time.Duration, onEvicted func(string, interface{})) *shardTable {
+	t := &shardTable{
+		m:     uint32(n),
+		cs:    make([]*cache, n),
+		gates: make([]sync.RWMutex, n),
+	}
+	for i := range t.cs {
+		t.cs[i] = &cache{
+			defaultExpiration: de,
+			items:             map[string]Item{},
+			onEvicted:         onEvicted,
+		}
+	}
+	return t
+}
+
+// 从系统CSPRNG读取种子，测试中可以替换为总是失败的Reader
+var seedReader io.Reader = rand.Reader
+
+// 决定从系统CSPRNG读取种子失败时的行为
+type seedFailurePolicy int
+
+const (
+	// 记录警告并使用math/rand生成的不安全种子继续（默认）
+	seedFailureInsecure seedFailurePolicy = iota
+	// 记录警告并使用withSeedFallback提供的种子继续
+	seedFailureFallback
+	// 不创建分片缓存，由unexportedNewShardedWithError返回错误
+	seedFailureError
+)
+
+// 创建分片缓存时使用的可选配置。与分片缓存本身一样是内部API，包外无法使用
+type shardedOption func(*shardedCache)
+
+// 使用带随机密钥的maphash代替djb33选择分片。当键来自不可信的输入时应启用此选项，
+// 因为攻击者可以构造大量落入同一分片的djb33碰撞键，使分片缓存退化为单个锁（HashDoS）。
+// maphash的种子每个缓存独立随机生成，无法从外部预测
+func withHardenedHash() shardedOption {
+	return func(sc *shardedCache) {
+		sc.hardened = true
+		sc.hashSeed = maphash.MakeSeed()
+	}
+}
+
+// 使用hash代替djb33选择分片，例如对长UUID键分布更均匀的FNV或xxhash，hash的seed参数即分片缓存的种子
+// hash为nil时仍使用djb33。与withHardenedHash同时使用时以withHardenedHash为准
+func withHash(hash func(seed uint32, k string) uint32) shardedOption {
+	return func(sc *shardedCache) {
+		if hash != nil {
+			sc.hash = hash
+		}
+	}
+}
+
+// 使用固定的djb33种子代替随机种子，使键到分片的分配可以复现，仅供测试使用
+// 固定种子会使碰撞键可以被预先构造，参见initSeed中关于种子的说明
+func withSeed(seed uint32) shardedOption {
+	return func(sc *shardedCache) {
+		sc.seed = seed
+		sc.seedSet = true
+	}
+}
+
+// 从系统CSPRNG读取种子失败时，使用seed代替不安全的伪随机种子继续
+// 调用方应从自己可信的随机来源获取seed
+func withSeedFallback(seed uint32) shardedOption {
+	return func(sc *shardedCache) {
+		sc.seedPolicy = seedFailureFallback
+		sc.fallbackSeed = seed
+	}
+}
+
+// 从系统CSPRNG读取种子失败时不继续创建，而是由unexportedNewShardedWithError返回错误
+func withSeedFailureError() shardedOption {
+	return func(sc *shardedCache) {
+		sc.seedPolicy = seedFailureError
+	}
+}
+
+// 设置输出警告的函数，默认写入os.Stderr。设置为nil以忽略警告
+func withLogger(logf func(msg string)) shardedOption {
+	return func(sc *shardedCache) {
+		sc.logf = logf
+	}
 }
 
 // 具有更好洗牌效果的djb2哈希算法。比带有hash.Hash开销的FNV快5倍。
@@ -59,62 +174,257 @@ func djb33(seed uint32, k string) uint32 {
 	return d ^ (d >> 16)
 }
 
-func (sc *shardedCache) bucket(k string) *cache {
-	return sc.cs[djb33(sc.seed, k)%sc.m]
+// 返回键k在有m个分片的分片表中的下标
+func (sc *shardedCache) index(m uint32, k string) uint32 {
+	if sc.hardened {
+		return uint32(maphash.String(sc.hashSeed, k) % uint64(m))
+	}
+	return sc.hash(sc.seed, k) % m
+}
+
+// 对键k所在的分片调用f，f返回之前Resize不会迁移这个分片
+func (sc *shardedCache) with(k string, f func(c *cache)) {
+	for {
+		t := sc.table.Load()
+		i := sc.index(t.m, k)
+		g := &t.gates[i]
+		g.RLock()
+		if !t.retired {
+			f(t.cs[i])
+			g.RUnlock()
+			return
+		}
+		// 等待期间Resize已经换上了新表
+		g.RUnlock()
+	}
+}
+
+// 对当前分片表调用f，f返回之前Resize不会替换分片表
+// 按下标顺序获取gate，与Resize的顺序相同，因此不会死锁
+func (sc *shardedCache) withAll(f func(t *shardTable)) {
+	for {
+		t := sc.table.Load()
+		for i := range t.gates {
+			t.gates[i].RLock()
+		}
+		retired := t.retired
+		if !retired {
+			f(t)
+		}
+		for i := range t.gates {
+			t.gates[i].RUnlock()
+		}
+		if !retired {
+			return
+		}
+	}
 }
 
 func (sc *shardedCache) Set(k string, x interface{}, d time.Duration) {
-	sc.bucket(k).Set(k, x, d)
+	sc.with(k, func(c *cache) {
+		c.Set(k, x, d)
+	})
 }
 
 func (sc *shardedCache) Add(k string, x interface{}, d time.Duration) error {
-	return sc.bucket(k).Add(k, x, d)
+	var err error
+	sc.with(k, func(c *cache) {
+		err = c.Add(k, x, d)
+	})
+	return err
 }
 
 func (sc *shardedCache) Replace(k string, x interface{}, d time.Duration) error {
-	return sc.bucket(k).Replace(k, x, d)
+	var err error
+	sc.with(k, func(c *cache) {
+		err = c.Replace(k, x, d)
+	})
+	return err
 }
 
 func (sc *shardedCache) Get(k string) (interface{}, bool) {
-	return sc.bucket(k).Get(k)
+	var v interface{}
+	var found bool
+	sc.with(k, func(c *cache) {
+		v, found = c.Get(k)
+	})
+	return v, found
 }
 
 func (sc *shardedCache) Increment(k string, n int64) error {
-	return sc.bucket(k).Increment(k, n)
+	var err error
+	sc.with(k, func(c *cache) {
+		err = c.Increment(k, n)
+	})
+	return err
 }
 
 func (sc *shardedCache) IncrementFloat(k string, n float64) error {
-	return sc.bucket(k).IncrementFloat(k, n)
+	var err error
+	sc.with(k, func(c *cache) {
+		err = c.IncrementFloat(k, n)
+	})
+	return err
 }
 
 func (sc *shardedCache) Decrement(k string, n int64) error {
-	return sc.bucket(k).Decrement(k, n)
+	var err error
+	sc.with(k, func(c *cache) {
+		err = c.Decrement(k, n)
+	})
+	return err
 }
 
 func (sc *shardedCache) Delete(k string) {
-	sc.bucket(k).Delete(k)
+	sc.with(k, func(c *cache) {
+		c.Delete(k)
+	})
 }
 
+// 已过期的项目在Resize时会触发onEvicted，因此不能在Resize之后再从旧表中删除它们
 func (sc *shardedCache) DeleteExpired() {
-	for _, v := range sc.cs {
-		v.DeleteExpired()
-	}
+	sc.withAll(func(t *shardTable) {
+		for _, v := range t.cs {
+			v.DeleteExpired()
+		}
+	})
 }
 
 // 返回缓存中的项目。这可能包括已过期但尚未清理的项目。
 // 如果这很重要，应检查项目的Expiration字段。请注意，
 // 需要显式同步才能同时使用缓存及其相应的Items()返回值，因为映射是共享的。
 func (sc *shardedCache) Items() []map[string]Item {
-	res := make([]map[string]Item, len(sc.cs))
-	for i, v := range sc.cs {
+	t := sc.table.Load()
+	res := make([]map[string]Item, len(t.cs))
+	for i, v := range t.cs {
 		res[i] = v.Items()
 	}
 	return res
 }
 
 func (sc *shardedCache) Flush() {
-	for _, v := range sc.cs {
-		v.Flush()
+	sc.withAll(func(t *shardTable) {
+		for _, v := range t.cs {
+			v.Flush()
+		}
+	})
+}
+
+// 仅清空给定索引的分片，不影响其他分片。被清除的每个项目都会触发onEvicted
+func (sc *shardedCache) FlushShard(index int) error {
+	var items map[string]Item
+	var onEvicted func(string, interface{})
+	for {
+		t := sc.table.Load()
+		if index < 0 || index >= len(t.cs) {
+			return fmt.Errorf("Shard index %d out of range [0, %d)", index, len(t.cs))
+		}
+		g := &t.gates[index]
+		g.RLock()
+		if t.retired {
+			g.RUnlock()
+			continue
+		}
+		c := t.cs[index]
+		c.mu.Lock()
+		items = c.items
+		c.items = map[string]Item{}
+		onEvicted = c.onEvicted
+		c.mu.Unlock()
+		g.RUnlock()
+		break
+	}
+	if onEvicted != nil {
+		for k, v := range items {
+			onEvicted(k, v.Object)
+		}
+	}
+	return nil
+}
+
+// 为所有分片设置一个（可选的）函数，当项目从缓存中驱逐时调用该函数
+// 设置为nil以禁用
+func (sc *shardedCache) OnEvicted(f func(string, interface{})) {
+	sc.withAll(func(t *shardTable) {
+		for _, v := range t.cs {
+			v.OnEvicted(f)
+		}
+	})
+}
+
+// 返回分片的数量
+func (sc *shardedCache) ShardCount() int {
+	return len(sc.table.Load().cs)
+}
+
+// 返回每个分片中未过期的项目数，下标与FlushShard的index一致，可用于检查键的分布是否均衡
+func (sc *shardedCache) ShardItemCounts() []int {
+	t := sc.table.Load()
+	res := make([]int, len(t.cs))
+	now := time.Now().UnixNano()
+	for i, c := range t.cs {
+		c.mu.RLock()
+		for _, v := range c.items {
+			if v.Expiration <= 0 || now <= v.Expiration {
+				res[i]++
+			}
+		}
+		c.mu.RUnlock()
+	}
+	return res
+}
+
+// 返回所有分片中的项目总数。这可能包括已过期但尚未清理的项目
+func (sc *shardedCache) ItemCount() int {
+	n := 0
+	for _, c := range sc.table.Load().cs {
+		n += c.ItemCount()
+	}
+	return n
+}
+
+// 将分片数改为n（小于1时按1处理），并把所有未过期的项目按原有的过期时间重新分配到新的分片中
+// 这是一个全局暂停的操作：在重新分配期间所有其他操作都会阻塞，耗时与项目总数成正比。
+// 已过期的项目不会被复制，它们会像被DeleteExpired删除一样触发onEvicted。
+// 注意Items之前返回的映射属于旧的分片，不会再被更新
+func (sc *shardedCache) Resize(n int) {
+	if n < 1 {
+		n = 1
+	}
+	type expiredItem struct {
+		key       string
+		value     interface{}
+		onEvicted func(string, interface{})
+	}
+	var expired []expiredItem
+	sc.resizeMu.Lock()
+	old := sc.table.Load()
+	for i := range old.gates {
+		old.gates[i].Lock()
+	}
+	t := newShardTable(n, old.cs[0].defaultExpiration, old.cs[0].onEvicted)
+	now := time.Now().UnixNano()
+	for _, c := range old.cs {
+		c.mu.Lock()
+		for k, v := range c.items {
+			if v.Expiration > 0 && now > v.Expiration {
+				if c.onEvicted != nil {
+					expired = append(expired, expiredItem{k, v.Object, c.onEvicted})
+				}
+				continue
+			}
+			t.cs[sc.index(t.m, k)].items[k] = v
+		}
+		c.mu.Unlock()
+	}
+	sc.table.Store(t)
+	old.retired = true
+	for i := range old.gates {
+		old.gates[i].Unlock()
+	}
+	sc.resizeMu.Unlock()
+	for _, v := range expired {
+		v.onEvicted(v.key, v.value)
 	}
 }
 
@@ -149,39 +459,78 @@ func runShardedJanitor(sc *shardedCache, ci time.Duration) {
 }
 
 func newShardedCache(n int, de time.Duration) *shardedCache {
+	sc := &shardedCache{
+		hash: djb33,
+		logf: func(msg string) {
+			os.Stderr.Write([]byte(msg + "\n"))
+		},
+	}
+	sc.table.Store(newShardTable(n, de, nil))
+	return sc
+}
+
+// 按seedPolicy为djb33生成种子
+//
+// djb33不是密码学哈希，如果种子可以被猜到，攻击者就能离线构造大量落入同一分片的键，
+// 使所有请求争用同一个锁（HashDoS）。因此种子从系统CSPRNG读取，而不是使用可预测的伪随机数
+func (sc *shardedCache) initSeed() error {
 	max := big.NewInt(0).SetUint64(uint64(math.MaxUint32))
-	rnd, err := rand.Int(rand.Reader, max)
-	var seed uint32
-	if err != nil {
-		os.Stderr.Write([]byte("WARNING: go-cache's newShardedCache failed to read from the system CSPRNG (/dev/urandom or equivalent.) Your system's security may be compromised. Continuing with an insecure seed.\n"))
-		seed = insecurerand.Uint32()
-	} else {
-		seed = uint32(rnd.Uint64())
+	rnd, err := rand.Int(seedReader, max)
+	if err == nil {
+		sc.seed = uint32(rnd.Uint64())
+		return nil
 	}
-	sc := &shardedCache{
-		seed: seed,
-		m:    uint32(n),
-		cs:   make([]*cache, n),
+	switch sc.seedPolicy {
+	case seedFailureError:
+		return fmt.Errorf("Failed to read a shard seed from the system CSPRNG: %w", err)
+	case seedFailureFallback:
+		sc.warn("WARNING: go-cache's newShardedCache failed to read from the system CSPRNG (/dev/urandom or equivalent.) Your system's security may be compromised. Continuing with the fallback seed.")
+		sc.seed = sc.fallbackSeed
+	default:
+		sc.warn("WARNING: go-cache's newShardedCache failed to read from the system CSPRNG (/dev/urandom or equivalent.) Your system's security may be compromised. Continuing with an insecure seed.")
+		sc.seed = insecurerand.Uint32()
 	}
-	for i := 0; i < n; i++ {
-		c := &cache{
-			defaultExpiration: de,
-			items:             map[string]Item{},
-		}
-		sc.cs[i] = c
+	return nil
+}
+
+func (sc *shardedCache) warn(msg string) {
+	if sc.logf != nil {
+		sc.logf(msg)
 	}
-	return sc
 }
 
-func unexportedNewSharded(defaultExpiration, cleanupInterval time.Duration, shards int) *unexportedShardedCache {
+// 创建一个有shards个分片的分片缓存（小于1时按1处理）。这是内部API，参见文件开头的说明
+func unexportedNewSharded(defaultExpiration, cleanupInterval time.Duration, shards int, opts ...shardedOption) *unexportedShardedCache {
+	SC, err := unexportedNewShardedWithError(defaultExpiration, cleanupInterval, shards, opts...)
+	if err != nil {
+		// 只有使用withSeedFailureError时才会出错
+		panic(err)
+	}
+	return SC
+}
+
+// 与unexportedNewSharded相同，但在使用withSeedFailureError且无法读取种子时返回错误
+func unexportedNewShardedWithError(defaultExpiration, cleanupInterval time.Duration, shards int, opts ...shardedOption) (*unexportedShardedCache, error) {
 	if defaultExpiration == 0 {
 		defaultExpiration = -1
 	}
+	// 分片数为0时index会除以零
+	if shards < 1 {
+		shards = 1
+	}
 	sc := newShardedCache(shards, defaultExpiration)
+	for _, opt := range opts {
+		opt(sc)
+	}
+	if !sc.seedSet {
+		if err := sc.initSeed(); err != nil {
+			return nil, err
+		}
+	}
 	SC := &unexportedShardedCache{sc}
 	if cleanupInterval > 0 {
 		runShardedJanitor(sc, cleanupInterval)
 		runtime.SetFinalizer(SC, stopShardedJanitor)
 	}
-	return SC
+	return SC, nil
 }
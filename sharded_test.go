@@ -1,8 +1,10 @@
 package cache
 
 import (
+	"errors"
 	"strconv"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 )
@@ -33,6 +35,318 @@ func TestShardedCache(t *testing.T) {
 	}
 }
 
+// 找出在给定种子下经djb33哈希后全部落入0号分片的键
+func djb33CollidingKeys(seed uint32, m uint32, n int) []string {
+	keys := make([]string, 0, n)
+	for i := 0; len(keys) < n; i++ {
+		k := "key" + strconv.Itoa(i)
+		if djb33(seed, k)%m == 0 {
+			keys = append(keys, k)
+		}
+	}
+	return keys
+}
+
+func nonEmptyShards(sc *unexportedShardedCache) int {
+	n := 0
+	for _, c := range sc.table.Load().cs {
+		if c.ItemCount() > 0 {
+			n++
+		}
+	}
+	return n
+}
+
+func TestShardedCacheHardenedHash(t *testing.T) {
+	plain := unexportedNewSharded(DefaultExpiration, 0, 16)
+	keys := djb33CollidingKeys(plain.seed, plain.table.Load().m, 200)
+	for _, k := range keys {
+		plain.Set(k, "value", DefaultExpiration)
+	}
+	if n := nonEmptyShards(plain); n != 1 {
+		t.Fatalf("Expected colliding keys to land in 1 shard with djb33, got %d", n)
+	}
+
+	hardened := unexportedNewSharded(DefaultExpiration, 0, 16, withHardenedHash())
+	hardened.seed = plain.seed
+	for _, k := range keys {
+		hardened.Set(k, "value", DefaultExpiration)
+	}
+	if n := nonEmptyShards(hardened); n < 8 {
+		t.Errorf("Expected colliding keys to spread across shards with hardened hash, got %d non-empty shards", n)
+	}
+	for _, k := range keys {
+		if _, found := hardened.Get(k); !found {
+			t.Errorf("%s was not found in hardened cache", k)
+		}
+	}
+}
+
+func TestShardedCacheFlushShard(t *testing.T) {
+	tc := unexportedNewSharded(DefaultExpiration, 0, 4)
+	var evicted int32
+	tc.OnEvicted(func(k string, v interface{}) {
+		atomic.AddInt32(&evicted, 1)
+	})
+	for i := 0; nonEmptyShards(tc) < 4; i++ {
+		tc.Set("key"+strconv.Itoa(i), i, DefaultExpiration)
+	}
+	before := tc.table.Load().cs[2].ItemCount()
+
+	if err := tc.FlushShard(2); err != nil {
+		t.Fatalf("FlushShard failed: %v", err)
+	}
+	for i, c := range tc.table.Load().cs {
+		n := c.ItemCount()
+		if i == 2 && n != 0 {
+			t.Errorf("Expected shard 2 to be empty, got %d items", n)
+		}
+		if i != 2 && n == 0 {
+			t.Errorf("Expected shard %d to be untouched, but it is empty", i)
+		}
+	}
+	if int(evicted) != before {
+		t.Errorf("Expected %d evictions, got %d", before, evicted)
+	}
+
+	if err := tc.FlushShard(4); err == nil {
+		t.Error("Expected error for out of range shard index")
+	}
+	if err := tc.FlushShard(-1); err == nil {
+		t.Error("Expected error for negative shard index")
+	}
+}
+
+func TestShardedCacheShardItemCounts(t *testing.T) {
+	tc := unexportedNewSharded(DefaultExpiration, 0, 8)
+	const n = 8000
+	for i := 0; i < n; i++ {
+		tc.Set("key"+strconv.Itoa(i), i, DefaultExpiration)
+	}
+	if tc.ShardCount() != 8 {
+		t.Errorf("Expected 8 shards, got %d", tc.ShardCount())
+	}
+	counts := tc.ShardItemCounts()
+	if len(counts) != 8 {
+		t.Fatalf("Expected 8 counts, got %d", len(counts))
+	}
+	total := 0
+	for i, c := range counts {
+		total += c
+		// 每个分片应接近n/8=1000，这里只做粗略检查
+		if c < n/8/2 || c > n/8*2 {
+			t.Errorf("Shard %d is unbalanced: %d items", i, c)
+		}
+	}
+	if total != tc.ItemCount() || total != n {
+		t.Errorf("Expected shard counts to sum to %d, got %d (ItemCount %d)", n, total, tc.ItemCount())
+	}
+
+	tc.Set("expired", 0, time.Nanosecond)
+	<-time.After(time.Millisecond)
+	total = 0
+	for _, c := range tc.ShardItemCounts() {
+		total += c
+	}
+	if total != n {
+		t.Errorf("Expected expired items to be excluded, got %d", total)
+	}
+}
+
+func TestShardedCacheZeroShards(t *testing.T) {
+	tc := unexportedNewSharded(DefaultExpiration, 0, 0)
+	if tc.ShardCount() != 1 {
+		t.Errorf("Expected shard count to be clamped to 1, got %d", tc.ShardCount())
+	}
+	tc.Set("a", 1, DefaultExpiration)
+	if v, found := tc.Get("a"); !found || v.(int) != 1 {
+		t.Errorf("Expected a=1, got %v, %v", v, found)
+	}
+}
+
+func TestShardedCacheFixedSeed(t *testing.T) {
+	a := unexportedNewSharded(DefaultExpiration, 0, 16, withSeed(12345))
+	b := unexportedNewSharded(DefaultExpiration, 0, 16, withSeed(12345))
+	for i := 0; i < 100; i++ {
+		k := "key" + strconv.Itoa(i)
+		if djb33(a.seed, k)%a.table.Load().m != djb33(12345, k)%16 {
+			t.Errorf("Key %s was not placed by the fixed seed", k)
+		}
+		a.Set(k, i, DefaultExpiration)
+		b.Set(k, i, DefaultExpiration)
+	}
+	ac, bc := a.ShardItemCounts(), b.ShardItemCounts()
+	for i := range ac {
+		if ac[i] != bc[i] {
+			t.Errorf("Shard %d differs between caches with the same seed: %d vs %d", i, ac[i], bc[i])
+		}
+	}
+}
+
+type failingReader struct{}
+
+func (failingReader) Read([]byte) (int, error) {
+	return 0, errors.New("entropy unavailable")
+}
+
+func TestShardedCacheSeedFailure(t *testing.T) {
+	orig := seedReader
+	seedReader = failingReader{}
+	defer func() { seedReader = orig }()
+
+	var logged []string
+	logf := func(msg string) { logged = append(logged, msg) }
+
+	if _, err := unexportedNewShardedWithError(DefaultExpiration, 0, 4, withSeedFailureError(), withLogger(logf)); err == nil {
+		t.Error("Expected an error with the error policy")
+	}
+	if len(logged) != 0 {
+		t.Errorf("Expected no warning with the error policy, got %v", logged)
+	}
+
+	sc, err := unexportedNewShardedWithError(DefaultExpiration, 0, 4, withSeedFallback(42), withLogger(logf))
+	if err != nil {
+		t.Fatalf("Unexpected error with the fallback policy: %v", err)
+	}
+	if sc.seed != 42 {
+		t.Errorf("Expected fallback seed 42, got %d", sc.seed)
+	}
+	if len(logged) != 1 {
+		t.Errorf("Expected one warning with the fallback policy, got %v", logged)
+	}
+
+	if _, err := unexportedNewShardedWithError(DefaultExpiration, 0, 4, withLogger(logf)); err != nil {
+		t.Fatalf("Unexpected error with the default policy: %v", err)
+	}
+	if len(logged) != 2 {
+		t.Errorf("Expected a warning with the default policy, got %v", logged)
+	}
+
+	// 固定种子不读取CSPRNG，因此不会失败
+	if _, err := unexportedNewShardedWithError(DefaultExpiration, 0, 4, withSeed(1), withSeedFailureError()); err != nil {
+		t.Errorf("Expected a fixed seed to bypass the CSPRNG, got %v", err)
+	}
+}
+
+// TestShardedCacheCustomHash 测试自定义哈希函数决定键所在的分片
+func TestShardedCacheCustomHash(t *testing.T) {
+	tc := unexportedNewSharded(DefaultExpiration, 0, 8, withHash(func(uint32, string) uint32 { return 3 }))
+	for _, k := range shardedKeys {
+		tc.Set(k, "value", DefaultExpiration)
+	}
+	for i, n := range tc.ShardItemCounts() {
+		if i == 3 && n != len(shardedKeys) {
+			t.Errorf("Expected all %d keys in shard 3, got %d", len(shardedKeys), n)
+		}
+		if i != 3 && n != 0 {
+			t.Errorf("Expected shard %d to be empty, got %d items", i, n)
+		}
+	}
+	for _, k := range shardedKeys {
+		if _, found := tc.Get(k); !found {
+			t.Errorf("Key %s not found", k)
+		}
+	}
+}
+
+// TestShardedCacheResize 测试从4个分片扩容到16个分片后所有键仍然可以读取，且过期时间不变
+func TestShardedCacheResize(t *testing.T) {
+	tc := unexportedNewSharded(DefaultExpiration, 0, 4)
+	for i, k := range shardedKeys {
+		tc.Set(k, i, time.Hour)
+	}
+	tc.Set("forever", "x", NoExpiration)
+	tc.Set("concurrent", 0, DefaultExpiration)
+	tc.Set("expired", "x", time.Nanosecond)
+	before := map[string]int64{}
+	for _, m := range tc.Items() {
+		for k, v := range m {
+			before[k] = v.Expiration
+		}
+	}
+	time.Sleep(time.Millisecond)
+
+	var wg sync.WaitGroup
+	stop := make(chan struct{})
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+				tc.Get(shardedKeys[0])
+				tc.Set("concurrent", 1, DefaultExpiration)
+			}
+		}
+	}()
+	tc.Resize(16)
+	close(stop)
+	wg.Wait()
+
+	if tc.ShardCount() != 16 {
+		t.Errorf("Expected 16 shards, got %d", tc.ShardCount())
+	}
+	for i, k := range shardedKeys {
+		if v, found := tc.Get(k); !found || v != i {
+			t.Errorf("Expected %s to be %d after resize, got %v, %v", k, i, v, found)
+		}
+	}
+	if _, found := tc.Get("expired"); found {
+		t.Error("Expired item survived the resize")
+	}
+	for _, m := range tc.Items() {
+		for k, v := range m {
+			if exp, ok := before[k]; ok && exp != v.Expiration {
+				t.Errorf("Expiration of %s changed from %d to %d", k, exp, v.Expiration)
+			}
+		}
+	}
+	if n := len(shardedKeys) + 2; tc.ItemCount() != n {
+		t.Errorf("Expected %d items after resize, got %d", n, tc.ItemCount())
+	}
+}
+
+// TestShardedCacheResizeConcurrentWrites 测试与Resize并发的写入不会丢失
+func TestShardedCacheResizeConcurrentWrites(t *testing.T) {
+	tc := unexportedNewSharded(DefaultExpiration, 0, 4)
+	for _, k := range shardedKeys {
+		tc.Set(k, int64(0), DefaultExpiration)
+	}
+
+	const workers, increments = 4, 2000
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < increments; i++ {
+				if err := tc.Increment(shardedKeys[i%len(shardedKeys)], 1); err != nil {
+					t.Errorf("Increment failed: %v", err)
+					return
+				}
+			}
+		}()
+	}
+	for _, n := range []int{16, 3, 8, 1, 32} {
+		tc.Resize(n)
+	}
+	wg.Wait()
+
+	var total int64
+	for _, k := range shardedKeys {
+		v, found := tc.Get(k)
+		if !found {
+			t.Fatalf("%s was lost during resize", k)
+		}
+		total += v.(int64)
+	}
+	if total != workers*increments {
+		t.Errorf("Expected %d increments to survive the resizes, got %d", workers*increments, total)
+	}
+}
+
 func BenchmarkShardedCacheGetExpiring(b *testing.B) {
 	benchmarkShardedCacheGet(b, 5*time.Minute)
 }
@@ -0,0 +1,76 @@
+package cache
+
+import (
+	"time"
+)
+
+// SetWithHardTTL 与Set相同，但项目在过期时间d之后、硬过期时间hard之前仍可以通过GetStale读取
+// （其他读取方法在d之后就视其为不存在），直到hard之后才会被清理程序删除。
+// hard从现在开始计算，小于d时按d处理，同样受SetMaxTTL限制；d为NoExpiration时hard无意义
+func (c *CachePro[T]) SetWithHardTTL(k string, x T, d, hard time.Duration) {
+	c.mustBeOpen()
+	c.mu.Lock()
+	e := c.expiration(d)
+	var he int64
+	if e > 0 {
+		if c.maxTTL > 0 {
+			hard = min(hard, c.maxTTL)
+		}
+		he = max(c.clock.Now().Add(hard).UnixNano(), e)
+	}
+	c.overwrite(k, ItemPro[T]{
+		Object:         x,
+		Expiration:     e,
+		HardExpiration: he,
+	})
+	c.unlockAndEvict(k)
+}
+
+// GetStale 从CachePro返回项目，即使它已经过期但尚未硬过期（参见SetWithHardTTL）
+// 返回值、是否已（软）过期以及是否找到。对于已过期但可读取的项目，如果设置了WithLoader，
+// 会在后台异步刷新该项目（同一个键只会同时刷新一次），刷新后的项目保留原有的过期宽限时长
+func (c *CachePro[T]) GetStale(k string) (value T, stale bool, found bool) {
+	c.mu.RLock()
+	item, ok := c.items[k]
+	c.mu.RUnlock()
+	if !ok || item.Negative {
+		return value, false, false
+	}
+	if !c.expired(item) {
+		return item.Object, false, true
+	}
+	if c.hardExpired(item) {
+		return value, false, false
+	}
+	if c.loader != nil {
+		c.load(k, func() (T, time.Duration, error) {
+			v, d, ok := c.loader(k)
+			if !ok {
+				return v, 0, errLoaderMiss
+			}
+			return v, d, nil
+		})
+	}
+	return item.Object, true, true
+}
+
+// 如果项目已经过了可以被删除的时间（没有硬过期时间时即过期时间）则返回true
+func (c *cachePro[T]) hardExpired(item ItemPro[T]) bool {
+	if item.HardExpiration > item.Expiration {
+		return c.clock.Now().UnixNano() > item.HardExpiration
+	}
+	return c.expired(item)
+}
+
+// 返回以过期时间d存储v时使用的项目。如果键k当前的项目带有硬过期时间，则新项目保留相同的宽限时长
+// 调用方必须持有写锁
+func (c *cachePro[T]) refreshedItem(k string, v T, d time.Duration) ItemPro[T] {
+	item := ItemPro[T]{
+		Object:     v,
+		Expiration: c.expiration(d),
+	}
+	if old, found := c.items[k]; found && item.Expiration > 0 && old.Expiration > 0 && old.HardExpiration > old.Expiration {
+		item.HardExpiration = item.Expiration + (old.HardExpiration - old.Expiration)
+	}
+	return item
+}
@@ -0,0 +1,105 @@
+package cache
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestGetStale 测试GetStale在过期时间和硬过期时间之间返回旧值
+func TestGetStale(t *testing.T) {
+	clk := newFakeClock()
+	tc := NewPro[int](DefaultExpiration, 0, nil, withClock[int](clk))
+	tc.SetWithHardTTL("a", 1, time.Minute, time.Hour)
+
+	if v, stale, found := tc.GetStale("a"); !found || stale || v != 1 {
+		t.Errorf("Expected fresh value 1, got %d, stale=%v, found=%v", v, stale, found)
+	}
+
+	clk.Advance(2 * time.Minute)
+	if _, found := tc.Get("a"); found {
+		t.Error("Get returned a soft-expired item")
+	}
+	tc.DeleteExpired()
+	if v, stale, found := tc.GetStale("a"); !found || !stale || v != 1 {
+		t.Errorf("Expected stale value 1, got %d, stale=%v, found=%v", v, stale, found)
+	}
+
+	clk.Advance(time.Hour)
+	if _, _, found := tc.GetStale("a"); found {
+		t.Error("GetStale returned a hard-expired item")
+	}
+	tc.DeleteExpired()
+	if tc.ItemCount() != 0 {
+		t.Error("DeleteExpired did not remove the hard-expired item")
+	}
+
+	tc.SetWithHardTTL("b", 2, time.Minute, time.Second)
+	clk.Advance(2 * time.Minute)
+	if _, _, found := tc.GetStale("b"); found {
+		t.Error("Hard TTL shorter than the TTL should behave like a plain Set")
+	}
+}
+
+// TestGetStaleRefresh 测试GetStale在设置了加载函数时后台刷新过期项目
+func TestGetStaleRefresh(t *testing.T) {
+	clk := newFakeClock()
+	var calls int32
+	loader := func(k string) (int, time.Duration, bool) {
+		return int(atomic.AddInt32(&calls, 1)) + 10, time.Minute, true
+	}
+	tc := NewPro[int](DefaultExpiration, 0, nil, withClock[int](clk), WithLoader[int](loader))
+	tc.SetWithHardTTL("a", 1, time.Minute, time.Hour)
+
+	clk.Advance(2 * time.Minute)
+	if v, stale, found := tc.GetStale("a"); !found || !stale || v != 1 {
+		t.Errorf("Expected stale value 1, got %d, stale=%v, found=%v", v, stale, found)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		v, stale, found := tc.GetStale("a")
+		if found && !stale && v == 11 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected refreshed value 11, got %d, stale=%v, found=%v", v, stale, found)
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	// 刷新后的项目保留原有的宽限时长
+	clk.Advance(30 * time.Minute)
+	if _, stale, found := tc.GetStale("a"); !found || !stale {
+		t.Errorf("Expected the refreshed item to keep its stale window, got stale=%v, found=%v", stale, found)
+	}
+}
+
+// TestHardTTLCappedAndExpireMatching 测试SetMaxTTL也限制硬过期时间，ExpireMatching使项目无法再通过GetStale读取
+func TestHardTTLCappedAndExpireMatching(t *testing.T) {
+	clk := newFakeClock()
+	tc := NewPro[int](DefaultExpiration, 0, nil, withClock[int](clk))
+	tc.SetMaxTTL(time.Minute)
+	tc.SetWithHardTTL("capped", 1, time.Second, time.Hour)
+	clk.Advance(2 * time.Minute)
+	if _, _, found := tc.GetStale("capped"); found {
+		t.Error("The hard TTL was not capped by SetMaxTTL")
+	}
+	tc.DeleteExpired()
+	if tc.ItemCount() != 0 {
+		t.Error("DeleteExpired kept an item past the max TTL")
+	}
+
+	tc.SetMaxTTL(0)
+	tc.SetWithHardTTL("a", 1, time.Minute, time.Hour)
+	if n := tc.ExpireMatching(func(k string) bool { return k == "a" }); n != 1 {
+		t.Errorf("Expected 1 item expired, got %d", n)
+	}
+	if _, _, found := tc.GetStale("a"); found {
+		t.Error("GetStale returned an item expired by ExpireMatching")
+	}
+	tc.DeleteExpired()
+	if tc.ItemCount() != 0 {
+		t.Error("DeleteExpired did not remove the item expired by ExpireMatching")
+	}
+}
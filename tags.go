@@ -0,0 +1,64 @@
+package cache
+
+import (
+	"maps"
+	"time"
+)
+
+// SetWithTags 与Set相同，但为项目附加一组标签（例如"source"="db"），可以通过GetTags读取，
+// 或用DeleteByTag批量删除。tags会被复制，之后修改传入的映射不影响缓存中的项目
+// 之后用Set等方法覆盖该项目会清除它的标签
+func (c *CachePro[T]) SetWithTags(k string, x T, d time.Duration, tags map[string]string) {
+	c.mustBeOpen()
+	var p *map[string]string
+	if tags != nil {
+		m := maps.Clone(tags)
+		p = &m
+	}
+	c.mu.Lock()
+	c.overwrite(k, ItemPro[T]{
+		Object:     x,
+		Expiration: c.expiration(d),
+		Tags:       p,
+	})
+	c.unlockAndEvict(k)
+}
+
+// GetTags 返回项目标签的副本以及是否找到该项目。项目没有标签时返回nil, true
+func (c *CachePro[T]) GetTags(k string) (map[string]string, bool) {
+	c.mu.RLock()
+	item, found := c.items[k]
+	c.mu.RUnlock()
+	if !found || item.Negative || c.expired(item) {
+		return nil, false
+	}
+	if item.Tags == nil {
+		return nil, true
+	}
+	return maps.Clone(*item.Tags), true
+}
+
+// DeleteByTag 在同一个写锁内删除所有标签key的值为value的项目（调用delFunc和onEvicted），并返回删除的数量
+func (c *CachePro[T]) DeleteByTag(key, value string) int {
+	var evictedItems []keyAndValuePro
+	n := 0
+	c.mu.Lock()
+	for k, item := range c.items {
+		if item.Tags == nil {
+			continue
+		}
+		if v, ok := (*item.Tags)[key]; !ok || v != value {
+			continue
+		}
+		v, evicted := c.delete(k)
+		if evicted {
+			evictedItems = append(evictedItems, keyAndValuePro{k, v})
+		}
+		n++
+	}
+	c.mu.Unlock()
+	for _, v := range evictedItems {
+		c.notifyEvicted(v.key, v.value)
+	}
+	return n
+}
@@ -0,0 +1,101 @@
+package cache
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+// TestSetWithTags 测试标签的写入、读取以及覆盖后被清除
+func TestSetWithTags(t *testing.T) {
+	clk := newFakeClock()
+	tc := NewPro[int](DefaultExpiration, 0, nil, withClock[int](clk))
+	tags := map[string]string{"source": "db", "tenant": "42"}
+	tc.SetWithTags("a", 1, time.Minute, tags)
+	tags["source"] = "changed"
+
+	got, found := tc.GetTags("a")
+	if !found || len(got) != 2 || got["source"] != "db" || got["tenant"] != "42" {
+		t.Errorf("Expected the original tags, got %v, %v", got, found)
+	}
+	got["tenant"] = "changed"
+	if again, _ := tc.GetTags("a"); again["tenant"] != "42" {
+		t.Error("Modifying the returned tags changed the cached item")
+	}
+	if v, found := tc.Get("a"); !found || v != 1 {
+		t.Errorf("Expected 1, got %d, %v", v, found)
+	}
+
+	tc.Set("b", 2, DefaultExpiration)
+	if got, found := tc.GetTags("b"); !found || got != nil {
+		t.Errorf("Expected nil tags for an untagged item, got %v, %v", got, found)
+	}
+	if _, found := tc.GetTags("missing"); found {
+		t.Error("GetTags found a missing item")
+	}
+
+	tc.Set("a", 3, DefaultExpiration)
+	if got, _ := tc.GetTags("a"); got != nil {
+		t.Errorf("Set did not clear the tags, got %v", got)
+	}
+
+	tc.SetWithTags("c", 4, time.Second, map[string]string{"x": "y"})
+	clk.Advance(2 * time.Second)
+	if _, found := tc.GetTags("c"); found {
+		t.Error("GetTags found an expired item")
+	}
+}
+
+// TestDeleteByTag 测试按标签批量删除只影响匹配的项目
+func TestDeleteByTag(t *testing.T) {
+	var deleted []int
+	tc := NewPro[int](DefaultExpiration, 0, func(v int) { deleted = append(deleted, v) })
+	tc.SetWithTags("a", 1, DefaultExpiration, map[string]string{"tenant": "42"})
+	tc.SetWithTags("b", 2, DefaultExpiration, map[string]string{"tenant": "42", "source": "db"})
+	tc.SetWithTags("c", 3, DefaultExpiration, map[string]string{"tenant": "7"})
+	tc.Set("d", 4, DefaultExpiration)
+
+	if n := tc.DeleteByTag("tenant", "42"); n != 2 {
+		t.Errorf("Expected 2 items deleted, got %d", n)
+	}
+	if len(deleted) != 2 {
+		t.Errorf("Expected delFunc to run twice, got %v", deleted)
+	}
+	if _, found := tc.Get("a"); found {
+		t.Error("a was not deleted")
+	}
+	if _, found := tc.Get("b"); found {
+		t.Error("b was not deleted")
+	}
+	if _, found := tc.Get("c"); !found {
+		t.Error("c was deleted")
+	}
+	if _, found := tc.Get("d"); !found {
+		t.Error("d was deleted")
+	}
+	if n := tc.DeleteByTag("tenant", "42"); n != 0 {
+		t.Errorf("Expected nothing to delete, got %d", n)
+	}
+}
+
+// TestTagsComparableAndSaved 测试带标签的ItemPro仍然可以用==比较，并且标签可以随Save和Load保存
+func TestTagsComparableAndSaved(t *testing.T) {
+	tc := NewPro[int](DefaultExpiration, 0, nil)
+	tc.SetWithTags("a", 1, DefaultExpiration, map[string]string{"tenant": "42"})
+	item := tc.Items()["a"]
+	if item != tc.Items()["a"] {
+		t.Error("Expected the same item to compare equal")
+	}
+
+	buf := &bytes.Buffer{}
+	if err := tc.Save(buf); err != nil {
+		t.Fatal(err)
+	}
+	oc := NewPro[int](DefaultExpiration, 0, nil)
+	if err := oc.Load(buf); err != nil {
+		t.Fatal(err)
+	}
+	if tags, found := oc.GetTags("a"); !found || tags["tenant"] != "42" {
+		t.Errorf("Expected the tags to survive Save and Load, got %v, %v", tags, found)
+	}
+}
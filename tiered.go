@@ -0,0 +1,48 @@
+package cache
+
+// 二级缓存（例如数据库或远程缓存）需要实现的接口
+type Backend[T any] interface {
+	// 返回项目以及是否找到该键。只有在后端本身出错时才返回错误
+	Get(k string) (T, bool, error)
+	// 存储项目
+	Set(k string, x T) error
+}
+
+// 以CachePro作为一级缓存（L1）、以Backend作为二级缓存（L2）的读穿透/写穿透缓存
+type TieredCache[T any] struct {
+	l1 *CachePro[T]
+	l2 Backend[T]
+}
+
+// 返回一个以l1为一级缓存、l2为二级缓存的新TieredCache
+func NewTiered[T any](l1 *CachePro[T], l2 Backend[T]) *TieredCache[T] {
+	return &TieredCache[T]{
+		l1: l1,
+		l2: l2,
+	}
+}
+
+// 先从L1获取项目；未命中时查询L2，并将找到的项目以L1的默认过期时间写入L1
+// 两级都未命中时返回(零值, false, nil)
+func (t *TieredCache[T]) Get(k string) (T, bool, error) {
+	if v, found := t.l1.Get(k); found {
+		return v, true, nil
+	}
+	v, found, err := t.l2.Get(k)
+	if err != nil || !found {
+		var zero T
+		return zero, false, err
+	}
+	t.l1.SetDefault(k, v)
+	return v, true, nil
+}
+
+// 将项目写入L2，成功后再以L1的默认过期时间写入L1
+// 如果写入L2失败则返回错误，L1保持不变
+func (t *TieredCache[T]) Set(k string, x T) error {
+	if err := t.l2.Set(k, x); err != nil {
+		return err
+	}
+	t.l1.SetDefault(k, x)
+	return nil
+}
@@ -0,0 +1,88 @@
+package cache
+
+import (
+	"errors"
+	"sync"
+	"testing"
+)
+
+// 用于测试的内存后端
+type fakeBackend[T any] struct {
+	mu    sync.Mutex
+	items map[string]T
+	gets  int
+	err   error
+}
+
+func newFakeBackend[T any]() *fakeBackend[T] {
+	return &fakeBackend[T]{items: map[string]T{}}
+}
+
+func (b *fakeBackend[T]) Get(k string) (T, bool, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.gets++
+	v, found := b.items[k]
+	return v, found, b.err
+}
+
+func (b *fakeBackend[T]) Set(k string, x T) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.err != nil {
+		return b.err
+	}
+	b.items[k] = x
+	return nil
+}
+
+// TestTieredCachePromotion 测试L1未命中时从L2提升
+func TestTieredCachePromotion(t *testing.T) {
+	l1 := NewPro[string](DefaultExpiration, 0, nil)
+	l2 := newFakeBackend[string]()
+	l2.items["a"] = "from-l2"
+	tc := NewTiered[string](l1, l2)
+
+	v, found, err := tc.Get("a")
+	if err != nil || !found || v != "from-l2" {
+		t.Fatalf("Expected 'from-l2', got '%v', %v, %v", v, found, err)
+	}
+	if v, found := l1.Get("a"); !found || v != "from-l2" {
+		t.Error("a was not promoted to L1")
+	}
+
+	tc.Get("a")
+	if l2.gets != 1 {
+		t.Errorf("Expected L2 to be consulted once, got %d", l2.gets)
+	}
+
+	v, found, err = tc.Get("missing")
+	if err != nil || found || v != "" {
+		t.Errorf("Expected (zero, false, nil) for a miss, got '%v', %v, %v", v, found, err)
+	}
+}
+
+// TestTieredCacheWriteThrough 测试写穿透
+func TestTieredCacheWriteThrough(t *testing.T) {
+	l1 := NewPro[int](DefaultExpiration, 0, nil)
+	l2 := newFakeBackend[int]()
+	tc := NewTiered[int](l1, l2)
+
+	if err := tc.Set("a", 1); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if v, found := l1.Get("a"); !found || v != 1 {
+		t.Error("a was not written to L1")
+	}
+	if l2.items["a"] != 1 {
+		t.Error("a was not written to L2")
+	}
+
+	l2.err = errors.New("backend down")
+	if err := tc.Set("b", 2); err == nil {
+		t.Error("Expected error when L2 fails")
+	}
+	if _, found := l1.Get("b"); found {
+		t.Error("b was written to L1 even though L2 failed")
+	}
+}
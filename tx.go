@@ -0,0 +1,55 @@
+package cache
+
+import (
+	"time"
+)
+
+// Tx 是Transaction回调中对CachePro的访问句柄，只能在回调返回之前使用
+type Tx[T any] struct {
+	c       *cachePro[T]
+	keys    []string
+	evicted []keyAndValuePro
+}
+
+// Transaction 在持有写锁的情况下调用f，f通过tx对多个键进行的读取和修改对其他goroutine来说是原子的：
+// 其他调用者要么看到f执行之前的状态，要么看到f返回之后的状态。
+// 修改直接作用于CachePro，没有回滚：f中途panic时已经执行的修改会保留。
+// 由于整个回调期间都持有写锁，f必须尽可能快，并且只能通过tx访问CachePro，直接调用CachePro的方法会死锁。
+// 删除触发的onEvicted在释放写锁之后调用。事务写入的所有键都不会被事务结束时的内存限制驱逐
+func (c *CachePro[T]) Transaction(f func(tx *Tx[T])) {
+	c.mustBeOpen()
+	tx := &Tx[T]{c: c.cachePro}
+	c.mu.Lock()
+	defer func() {
+		// f发生panic时tx.c仍然有效，需要在这里释放写锁
+		if tx.c != nil {
+			tx.c = nil
+			c.mu.Unlock()
+		}
+	}()
+	f(tx)
+	tx.c = nil
+	c.unlockAndEvict(tx.keys...)
+	for _, v := range tx.evicted {
+		c.notifyEvicted(v.key, v.value)
+	}
+}
+
+// Get 返回键k未过期的值以及是否找到
+func (tx *Tx[T]) Get(k string) (T, bool) {
+	return tx.c.get(k)
+}
+
+// Set 以过期时间d存储x，替换任何现有项目
+func (tx *Tx[T]) Set(k string, x T, d time.Duration) {
+	tx.c.set(k, x, d)
+	tx.keys = append(tx.keys, k)
+}
+
+// Delete 删除键k（如果存在）
+func (tx *Tx[T]) Delete(k string) {
+	v, evicted := tx.c.delete(k)
+	if evicted {
+		tx.evicted = append(tx.evicted, keyAndValuePro{k, v})
+	}
+}
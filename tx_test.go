@@ -0,0 +1,102 @@
+package cache
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+// TestTransaction 测试在并发读取者看来两个键之间的转账总是保持总和不变
+func TestTransaction(t *testing.T) {
+	tc := NewPro[int](DefaultExpiration, 0, nil)
+	tc.Set("a", 100, DefaultExpiration)
+	tc.Set("b", 0, DefaultExpiration)
+
+	var stop atomic.Bool
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for !stop.Load() {
+				var sum int
+				tc.Transaction(func(tx *Tx[int]) {
+					a, _ := tx.Get("a")
+					b, _ := tx.Get("b")
+					sum = a + b
+				})
+				if sum != 100 {
+					t.Errorf("Expected the sum to stay 100, got %d", sum)
+					return
+				}
+			}
+		}()
+	}
+	for i := 0; i < 1000; i++ {
+		tc.Transaction(func(tx *Tx[int]) {
+			a, _ := tx.Get("a")
+			b, _ := tx.Get("b")
+			if a == 0 {
+				a, b = b, a
+			}
+			tx.Set("a", a-1, DefaultExpiration)
+			tx.Set("b", b+1, DefaultExpiration)
+		})
+	}
+	stop.Store(true)
+	wg.Wait()
+
+	a, _ := tc.Get("a")
+	b, _ := tc.Get("b")
+	if a+b != 100 {
+		t.Errorf("Expected the final sum to be 100, got %d", a+b)
+	}
+}
+
+// TestTransactionDelete 测试事务中的删除在释放锁之后触发onEvicted，panic时释放写锁
+func TestTransactionDelete(t *testing.T) {
+	tc := NewPro[int](DefaultExpiration, 0, nil)
+	var evicted []string
+	tc.OnEvicted(func(k string, v interface{}) {
+		evicted = append(evicted, k)
+		// 回调在释放写锁之后调用，因此可以访问CachePro
+		tc.Get(k)
+	})
+	tc.Set("a", 1, DefaultExpiration)
+	tc.Transaction(func(tx *Tx[int]) {
+		tx.Delete("a")
+		tx.Delete("absent")
+	})
+	if len(evicted) != 1 || evicted[0] != "a" {
+		t.Errorf("Expected onEvicted for a, got %v", evicted)
+	}
+
+	func() {
+		defer func() { recover() }()
+		tc.Transaction(func(tx *Tx[int]) {
+			panic("boom")
+		})
+	}()
+	tc.Set("b", 2, DefaultExpiration)
+	if v, _ := tc.Get("b"); v != 2 {
+		t.Error("Cache unusable after a panicking transaction")
+	}
+}
+
+// TestTransactionKeepsAllSetKeys 测试事务结束时的内存限制驱逐不会驱逐事务中写入的任何键
+func TestTransactionKeepsAllSetKeys(t *testing.T) {
+	tc := NewProWithMemLimit[int](DefaultExpiration, 0, 1, func(int) int64 { return 1 }, nil)
+	tc.Set("old", 0, DefaultExpiration)
+	tc.Transaction(func(tx *Tx[int]) {
+		tx.Set("a", 1, DefaultExpiration)
+		tx.Set("b", 2, DefaultExpiration)
+	})
+	if _, found := tc.Get("old"); found {
+		t.Error("Expected old to be evicted")
+	}
+	for _, k := range []string{"a", "b"} {
+		if _, found := tc.Get(k); !found {
+			t.Errorf("Expected %s set in the transaction to be kept", k)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package cache
+
+import "context"
+
+// WaitForKey 返回键k的值。如果k不存在或已过期，则阻塞直到其他goroutine写入k
+// （Set、Add等任何写入方法，负缓存项目除外）或ctx结束，后者返回ctx.Err()
+// 没有等待者时写入只多一次长度判断
+func (c *CachePro[T]) WaitForKey(ctx context.Context, k string) (T, error) {
+	for {
+		if v, found := c.Get(k); found {
+			return v, nil
+		}
+		c.mu.Lock()
+		// 在持有写锁时再检查一次，以免错过Get之后、注册之前的写入
+		if item, found := c.items[k]; found && !item.Negative && !c.expired(item) {
+			c.mu.Unlock()
+			return item.Object, nil
+		}
+		ch := make(chan struct{})
+		if c.waiters == nil {
+			c.waiters = make(map[string][]chan struct{})
+		}
+		c.waiters[k] = append(c.waiters[k], ch)
+		c.mu.Unlock()
+
+		select {
+		case <-ch:
+			// 项目可能在被唤醒后又被删除，重新检查
+		case <-ctx.Done():
+			c.mu.Lock()
+			c.removeWaiter(k, ch)
+			c.mu.Unlock()
+			var zero T
+			return zero, ctx.Err()
+		}
+	}
+}
+
+// 唤醒所有等待键k的goroutine，调用方必须持有写锁
+func (c *cachePro[T]) wake(k string) {
+	for _, ch := range c.waiters[k] {
+		close(ch)
+	}
+	delete(c.waiters, k)
+}
+
+// 取消注册一个等待者，调用方必须持有写锁。该等待者已被唤醒时什么也不做
+func (c *cachePro[T]) removeWaiter(k string, ch chan struct{}) {
+	chs := c.waiters[k]
+	for i, w := range chs {
+		if w == ch {
+			chs = append(chs[:i:i], chs[i+1:]...)
+			break
+		}
+	}
+	if len(chs) == 0 {
+		delete(c.waiters, k)
+	} else {
+		c.waiters[k] = chs
+	}
+}
@@ -0,0 +1,57 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// TestWaitForKey 测试等待者在另一个goroutine写入键后被唤醒
+func TestWaitForKey(t *testing.T) {
+	tc := NewPro[int](DefaultExpiration, 0, nil)
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		tc.Set("other", 1, DefaultExpiration)
+		tc.Set("a", 42, DefaultExpiration)
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	v, err := tc.WaitForKey(ctx, "a")
+	if err != nil || v != 42 {
+		t.Fatalf("Expected 42, got %d, %v", v, err)
+	}
+	if len(tc.waiters) != 0 {
+		t.Errorf("Expected no registered waiters, got %d", len(tc.waiters))
+	}
+
+	// 已存在的键立即返回
+	if v, err := tc.WaitForKey(context.Background(), "a"); err != nil || v != 42 {
+		t.Errorf("Expected 42 immediately, got %d, %v", v, err)
+	}
+}
+
+// TestWaitForKeyTimeout 测试ctx结束时返回ctx.Err()并取消注册
+func TestWaitForKeyTimeout(t *testing.T) {
+	tc := NewPro[int](DefaultExpiration, 0, nil)
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := tc.WaitForKey(ctx, "missing"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected DeadlineExceeded, got %v", err)
+	}
+	if len(tc.waiters) != 0 {
+		t.Errorf("Expected the waiter to be removed, got %d", len(tc.waiters))
+	}
+
+	// 负缓存项目不会唤醒等待者
+	ctx, cancel = context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		tc.SetNegative("missing", DefaultExpiration)
+	}()
+	if _, err := tc.WaitForKey(ctx, "missing"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected a negative entry not to satisfy the wait, got %v", err)
+	}
+}
@@ -0,0 +1,68 @@
+package cache
+
+// EventType 表示CacheEvent的类型
+type EventType int
+
+const (
+	// 项目被写入（包括覆盖）
+	EventSet EventType = iota
+	// 项目被删除（包括手动删除、Flush和因内存上限被驱逐）
+	EventDeleted
+	// 已过期的项目被删除（清理程序、DeleteExpired或WithLazyEvict的读取）
+	EventExpired
+)
+
+func (t EventType) String() string {
+	switch t {
+	case EventSet:
+		return "Set"
+	case EventDeleted:
+		return "Deleted"
+	case EventExpired:
+		return "Expired"
+	}
+	return "Unknown"
+}
+
+// CacheEvent 是Watch返回的通道中的一个事件
+type CacheEvent struct {
+	Key  string
+	Type EventType
+}
+
+// 每个订阅者的通道缓冲区大小
+const watchBufferSize = 256
+
+// Watch 订阅CachePro的变更事件，返回事件通道和取消订阅的函数
+//
+// 事件在持有写锁时以非阻塞方式发送：订阅者的缓冲区（watchBufferSize个事件）已满时，
+// 新的事件会被直接丢弃，因此慢速的订阅者不会阻塞CachePro，但可能错过事件。
+// 取消订阅后通道会被关闭；取消订阅函数可以重复调用
+func (c *CachePro[T]) Watch() (<-chan CacheEvent, func()) {
+	ch := make(chan CacheEvent, watchBufferSize)
+	c.mu.Lock()
+	c.watchers = append(c.watchers, ch)
+	c.mu.Unlock()
+	return ch, func() {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		for i, w := range c.watchers {
+			if w == ch {
+				c.watchers = append(c.watchers[:i:i], c.watchers[i+1:]...)
+				close(ch)
+				return
+			}
+		}
+	}
+}
+
+// 向所有订阅者发送一个事件，调用方必须持有写锁
+func (c *cachePro[T]) emit(k string, t EventType) {
+	ev := CacheEvent{Key: k, Type: t}
+	for _, ch := range c.watchers {
+		select {
+		case ch <- ev:
+		default:
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package cache
+
+import (
+	"testing"
+	"time"
+)
+
+// 在不阻塞的情况下取出通道中当前所有的事件
+func drainEvents(ch <-chan CacheEvent) []CacheEvent {
+	var evs []CacheEvent
+	for {
+		select {
+		case ev, ok := <-ch:
+			if !ok {
+				return evs
+			}
+			evs = append(evs, ev)
+		default:
+			return evs
+		}
+	}
+}
+
+// TestWatch 测试订阅者收到写入、删除和过期事件
+func TestWatch(t *testing.T) {
+	clk := newFakeClock()
+	tc := NewPro[int](DefaultExpiration, 0, nil, withClock[int](clk))
+	ch, unsubscribe := tc.Watch()
+	defer unsubscribe()
+
+	tc.Set("a", 1, DefaultExpiration)
+	tc.Set("b", 2, time.Second)
+	tc.Delete("a")
+	tc.Delete("missing")
+	clk.Advance(2 * time.Second)
+	tc.DeleteExpired()
+
+	want := []CacheEvent{
+		{"a", EventSet},
+		{"b", EventSet},
+		{"a", EventDeleted},
+		{"b", EventExpired},
+	}
+	got := drainEvents(ch)
+	if len(got) != len(want) {
+		t.Fatalf("Expected events %v, got %v", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Event %d: expected %v, got %v", i, want[i], got[i])
+		}
+	}
+}
+
+// TestWatchUnsubscribe 测试取消订阅后不再收到事件，且通道被关闭
+func TestWatchUnsubscribe(t *testing.T) {
+	tc := NewPro[int](DefaultExpiration, 0, nil)
+	ch, unsubscribe := tc.Watch()
+	other, unsubscribeOther := tc.Watch()
+	defer unsubscribeOther()
+
+	tc.Set("a", 1, DefaultExpiration)
+	unsubscribe()
+	unsubscribe()
+	tc.Set("b", 2, DefaultExpiration)
+
+	got := drainEvents(ch)
+	if len(got) != 1 || got[0].Key != "a" {
+		t.Errorf("Expected only the event before unsubscribing, got %v", got)
+	}
+	if _, ok := <-ch; ok {
+		t.Error("Expected channel to be closed after unsubscribing")
+	}
+	if got := drainEvents(other); len(got) != 2 {
+		t.Errorf("Expected the other subscriber to keep receiving events, got %v", got)
+	}
+}
+
+// TestWatchSlowSubscriber 测试缓冲区已满时丢弃事件而不阻塞写入
+func TestWatchSlowSubscriber(t *testing.T) {
+	tc := NewPro[int](DefaultExpiration, 0, nil)
+	ch, unsubscribe := tc.Watch()
+	defer unsubscribe()
+	for i := 0; i < watchBufferSize*2; i++ {
+		tc.Set("a", i, DefaultExpiration)
+	}
+	if got := drainEvents(ch); len(got) != watchBufferSize {
+		t.Errorf("Expected %d buffered events, got %d", watchBufferSize, len(got))
+	}
+}
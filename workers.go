@@ -0,0 +1,147 @@
+package cache
+
+import (
+	"sync"
+)
+
+// CachePro后台任务（如GetOrLoad的加载）默认使用的最大goroutine数
+const defaultMaxWorkers = 64
+
+// 由CachePro持有的有上限的goroutine池。所有后台任务都经由它执行，
+// 因此无论提交多少任务，同时存在的goroutine数都不会超过max。
+// 超出的任务在队列中等待，不会阻塞提交者
+type workerPool struct {
+	mu      sync.Mutex
+	max     int
+	workers int
+	queue   []func()
+}
+
+func newWorkerPool(max int) *workerPool {
+	if max < 1 {
+		max = 1
+	}
+	return &workerPool{max: max}
+}
+
+// 提交一个任务。如果当前goroutine数未达到上限则启动一个新的goroutine，否则排队等待
+func (p *workerPool) submit(f func()) {
+	p.mu.Lock()
+	p.queue = append(p.queue, f)
+	if p.workers < p.max {
+		p.workers++
+		go p.work()
+	}
+	p.mu.Unlock()
+}
+
+func (p *workerPool) work() {
+	for {
+		p.mu.Lock()
+		if len(p.queue) == 0 || p.workers > p.max {
+			p.workers--
+			p.mu.Unlock()
+			return
+		}
+		f := p.queue[0]
+		p.queue[0] = nil
+		p.queue = p.queue[1:]
+		p.mu.Unlock()
+		f()
+	}
+}
+
+// 返回当前存活的goroutine数
+func (p *workerPool) active() int {
+	p.mu.Lock()
+	n := p.workers
+	p.mu.Unlock()
+	return n
+}
+
+// 修改goroutine数上限。调低上限时，多出的goroutine在完成当前任务后退出
+func (p *workerPool) setMax(max int) {
+	if max < 1 {
+		max = 1
+	}
+	p.mu.Lock()
+	p.max = max
+	for p.workers < p.max && p.workers < len(p.queue) {
+		p.workers++
+		go p.work()
+	}
+	p.mu.Unlock()
+}
+
+// 在workerPool上按提交顺序逐个执行任务的队列，同一队列中的任务不会并发执行
+type serialQueue struct {
+	pool    *workerPool
+	mu      sync.Mutex
+	cond    *sync.Cond
+	tasks   []func()
+	running bool
+	limit   int // 等待中的任务数上限，0表示不限制
+}
+
+func newSerialQueue(pool *workerPool, limit int) *serialQueue {
+	q := &serialQueue{
+		pool:  pool,
+		limit: limit,
+	}
+	q.cond = sync.NewCond(&q.mu)
+	return q
+}
+
+// 将任务追加到队列末尾。如果等待中的任务数已达到上限则丢弃该任务并返回false
+func (q *serialQueue) push(f func()) bool {
+	q.mu.Lock()
+	if q.limit > 0 && len(q.tasks) >= q.limit {
+		q.mu.Unlock()
+		return false
+	}
+	q.tasks = append(q.tasks, f)
+	if !q.running {
+		q.running = true
+		q.pool.submit(q.drain)
+	}
+	q.mu.Unlock()
+	return true
+}
+
+func (q *serialQueue) drain() {
+	for {
+		q.mu.Lock()
+		if len(q.tasks) == 0 {
+			q.running = false
+			q.cond.Broadcast()
+			q.mu.Unlock()
+			return
+		}
+		f := q.tasks[0]
+		q.tasks[0] = nil
+		q.tasks = q.tasks[1:]
+		q.mu.Unlock()
+		f()
+	}
+}
+
+// 阻塞直到队列中的所有任务都执行完毕。不能在队列中的任务里调用
+func (q *serialQueue) wait() {
+	q.mu.Lock()
+	for q.running || len(q.tasks) > 0 {
+		q.cond.Wait()
+	}
+	q.mu.Unlock()
+}
+
+// 返回CachePro当前用于执行后台任务的goroutine数
+func (c *CachePro[T]) ActiveWorkers() int {
+	return c.workers.active()
+}
+
+// 设置CachePro用于执行后台任务的goroutine数上限，小于1时按1处理
+//
+// 后台任务不应同步等待同一CachePro的其他后台任务完成，否则在达到上限时可能死锁
+func (c *CachePro[T]) SetMaxWorkers(n int) {
+	c.workers.setMax(n)
+}
@@ -0,0 +1,62 @@
+package cache
+
+import (
+	"context"
+	"runtime"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestWorkerPoolBounded 测试后台任务的goroutine数不超过上限，且所有任务都能完成
+func TestWorkerPoolBounded(t *testing.T) {
+	tc := NewPro[int](DefaultExpiration, 0, nil)
+	tc.SetMaxWorkers(4)
+
+	base := runtime.NumGoroutine()
+	var running, peak int32
+	loader := func(ctx context.Context) (int, error) {
+		n := atomic.AddInt32(&running, 1)
+		for {
+			p := atomic.LoadInt32(&peak)
+			if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+		atomic.AddInt32(&running, -1)
+		return 1, nil
+	}
+
+	n := 40
+	wg := new(sync.WaitGroup)
+	wg.Add(n)
+	for i := 0; i < n; i++ {
+		go func() {
+			defer wg.Done()
+			tc.GetOrLoad(context.Background(), "k"+strconv.Itoa(i), loader, DefaultExpiration)
+		}()
+	}
+
+	time.Sleep(10 * time.Millisecond)
+	if w := tc.ActiveWorkers(); w > 4 {
+		t.Errorf("Expected at most 4 active workers, got %d", w)
+	}
+	wg.Wait()
+
+	if peak > 4 {
+		t.Errorf("Expected at most 4 concurrent loads, got %d", peak)
+	}
+	if tc.ItemCount() != n {
+		t.Errorf("Expected %d items after all loads completed, got %d", n, tc.ItemCount())
+	}
+	time.Sleep(10 * time.Millisecond)
+	if tc.ActiveWorkers() != 0 {
+		t.Errorf("Expected idle workers to exit, got %d", tc.ActiveWorkers())
+	}
+	if g := runtime.NumGoroutine(); g > base+1 {
+		t.Errorf("Expected goroutines to return to about %d, got %d", base, g)
+	}
+}
@@ -0,0 +1,91 @@
+package cache
+
+import (
+	"sync"
+	"time"
+)
+
+// 写回（write-behind）状态：记录自上次成功刷新以来被写入的键及其最新值
+type writeBehindPro[T any] struct {
+	flush func(dirty map[string]T) error
+	mu    sync.Mutex // 保护dirty
+	dirty map[string]T
+	fmu   sync.Mutex // 保证同一时间只有一次刷新，以免旧值覆盖新值
+	stop  chan struct{}
+}
+
+// 启用写回模式：每次写入都会把键标记为脏，后台goroutine每隔interval调用一次flush，
+// 传入自上次成功刷新以来所有被写入的键及其最新值。flush成功后这些键被清除；
+// 返回错误时它们会被保留并在下一次刷新时重试（期间被再次写入的键以新值为准）
+//
+// 删除和过期不会传递给flush。interval小于等于0时不启动后台goroutine，只能通过FlushNow刷新。
+// CachePro被回收时后台goroutine会停止，但不会刷新剩余的脏键，关闭前请调用FlushNow
+func WithWriteBehind[T any](flush func(dirty map[string]T) error, interval time.Duration) OptionPro[T] {
+	return func(c *cachePro[T]) {
+		wb := &writeBehindPro[T]{
+			flush: flush,
+			dirty: make(map[string]T),
+			stop:  make(chan struct{}),
+		}
+		c.writeBehind = wb
+		if interval > 0 {
+			go wb.run(interval)
+		}
+	}
+}
+
+// 将键标记为脏，调用方必须持有CachePro的写锁
+func (wb *writeBehindPro[T]) mark(k string, v T) {
+	wb.mu.Lock()
+	wb.dirty[k] = v
+	wb.mu.Unlock()
+}
+
+// 取出当前的脏键集合并调用flush，失败时把未被再次写入的键放回
+// 写入者只会在交换脏键集合时短暂阻塞，不会等待flush
+func (wb *writeBehindPro[T]) flushDirty() error {
+	wb.fmu.Lock()
+	defer wb.fmu.Unlock()
+	wb.mu.Lock()
+	batch := wb.dirty
+	if len(batch) == 0 {
+		wb.mu.Unlock()
+		return nil
+	}
+	wb.dirty = make(map[string]T)
+	wb.mu.Unlock()
+
+	err := wb.flush(batch)
+	if err != nil {
+		wb.mu.Lock()
+		for k, v := range batch {
+			if _, ok := wb.dirty[k]; !ok {
+				wb.dirty[k] = v
+			}
+		}
+		wb.mu.Unlock()
+	}
+	return err
+}
+
+func (wb *writeBehindPro[T]) run(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			wb.flushDirty()
+		case <-wb.stop:
+			return
+		}
+	}
+}
+
+// FlushNow 立即把所有脏键刷新到写回目标，并返回flush的错误。如果未启用写回模式则不执行任何操作
+// 适用于程序退出前的收尾
+func (c *CachePro[T]) FlushNow() error {
+	if c.writeBehind == nil {
+		return nil
+	}
+	return c.writeBehind.flushDirty()
+}
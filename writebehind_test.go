@@ -0,0 +1,107 @@
+package cache
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// 记录每次刷新的批次，可以设置为返回错误
+type fakeFlusher struct {
+	mu      sync.Mutex
+	batches []map[string]int
+	err     error
+}
+
+func (f *fakeFlusher) flush(dirty map[string]int) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.err != nil {
+		return f.err
+	}
+	f.batches = append(f.batches, dirty)
+	return nil
+}
+
+func (f *fakeFlusher) setErr(err error) {
+	f.mu.Lock()
+	f.err = err
+	f.mu.Unlock()
+}
+
+func (f *fakeFlusher) snapshot() []map[string]int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]map[string]int(nil), f.batches...)
+}
+
+// TestWriteBehindBatching 测试多次写入被合并为一个批次，且只包含每个键的最新值
+func TestWriteBehindBatching(t *testing.T) {
+	f := &fakeFlusher{}
+	tc := NewPro[int](DefaultExpiration, 0, nil, WithWriteBehind[int](f.flush, 0))
+	tc.Set("a", 1, DefaultExpiration)
+	tc.Set("a", 2, DefaultExpiration)
+	tc.Set("b", 3, DefaultExpiration)
+
+	if err := tc.FlushNow(); err != nil {
+		t.Fatal(err)
+	}
+	batches := f.snapshot()
+	if len(batches) != 1 {
+		t.Fatalf("Expected 1 batch, got %d", len(batches))
+	}
+	if len(batches[0]) != 2 || batches[0]["a"] != 2 || batches[0]["b"] != 3 {
+		t.Errorf("Unexpected batch: %v", batches[0])
+	}
+
+	if err := tc.FlushNow(); err != nil {
+		t.Fatal(err)
+	}
+	if len(f.snapshot()) != 1 {
+		t.Error("FlushNow called flush with no dirty keys")
+	}
+}
+
+// TestWriteBehindRetry 测试刷新失败时保留脏键，并在下一次刷新时以最新值重试
+func TestWriteBehindRetry(t *testing.T) {
+	f := &fakeFlusher{}
+	tc := NewPro[int](DefaultExpiration, 0, nil, WithWriteBehind[int](f.flush, 0))
+	tc.Set("a", 1, DefaultExpiration)
+	tc.Set("b", 2, DefaultExpiration)
+
+	errDown := errors.New("backend down")
+	f.setErr(errDown)
+	if err := tc.FlushNow(); !errors.Is(err, errDown) {
+		t.Fatalf("Expected flush error, got %v", err)
+	}
+	tc.Set("b", 20, DefaultExpiration)
+
+	f.setErr(nil)
+	if err := tc.FlushNow(); err != nil {
+		t.Fatal(err)
+	}
+	batches := f.snapshot()
+	if len(batches) != 1 {
+		t.Fatalf("Expected 1 successful batch, got %d", len(batches))
+	}
+	if batches[0]["a"] != 1 || batches[0]["b"] != 20 {
+		t.Errorf("Expected retried batch with the newest values, got %v", batches[0])
+	}
+}
+
+// TestWriteBehindInterval 测试后台goroutine定期刷新
+func TestWriteBehindInterval(t *testing.T) {
+	f := &fakeFlusher{}
+	tc := NewPro[int](DefaultExpiration, 0, nil, WithWriteBehind[int](f.flush, time.Millisecond))
+	tc.Set("a", 1, DefaultExpiration)
+
+	deadline := time.Now().Add(time.Second)
+	for len(f.snapshot()) == 0 && time.Now().Before(deadline) {
+		<-time.After(time.Millisecond)
+	}
+	batches := f.snapshot()
+	if len(batches) != 1 || batches[0]["a"] != 1 {
+		t.Errorf("Expected a background flush of a, got %v", batches)
+	}
+}